@@ -1,9 +1,13 @@
 package gdit
 
 import (
+	stdctx "context"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/saweima12/gdit/internal/ext"
 )
@@ -22,6 +26,8 @@ func (ls LifeState) String() string {
 		return "SHUTTING_DOWN"
 	case STATE_TERMINATED:
 		return "TERMINATED"
+	case STATE_FAILED:
+		return "FAILED"
 	default:
 		return fmt.Sprintf("UNKNOWN(%d)", int(ls))
 	}
@@ -33,6 +39,8 @@ const (
 	STATE_READY
 	STATE_SHUTTING_DOWN
 	STATE_TERMINATED
+	// STATE_FAILED is entered when a start hook fails, after the started components are stopped.
+	STATE_FAILED
 )
 
 type App interface {
@@ -40,18 +48,25 @@ type App interface {
 
 	// Startup initializes and starts the application. It executes all registered OnStart hooks
 	// in their respective order. An error is returned if any part of the initialization process fails.
+	// In that case the components already started are stopped in reverse order, and the app moves to
+	// STATE_FAILED. The error includes the start failure and any failure of this rollback.
 	Startup() error
 
 	// Teardown gracefully stops the application. It executes all registered OnStop hooks
-	// in reverse order to ensure proper cleanup. An error is returned if the teardown process encounters issues.
+	// in reverse order to ensure proper cleanup, the sub scopes before the root within each phase,
+	// as a rollback does. Every hook runs even if a previous one fails or panics,
+	// the failures are returned as a *LifecycleError listing each hook with its scope and owner.
 	Teardown() error
 
 	// SetLogger assigns a custom logger to the application for capturing runtime logs.
+	// A StructuredLogger receives the attributes of the logs as fields, and the app adopts
+	// the level of a logger created by NewSlogLogger.
 	// Returns a reference to the App for method chaining.
 	SetLogger(logger Logger) App
 
 	// SetLogLevel adjusts the logging level of the application's logger.
 	// This controls the verbosity of the application logs at runtime.
+	// The level of a logger created by NewSlogLogger is changed along.
 	// Returns a reference to the App for method chaining.
 	SetLogLevel(level LogLevel) App
 
@@ -59,17 +74,108 @@ type App interface {
 	// Scopes are used to manage service lifecycles and dependencies in a modular fashion.
 	// If the scope does not exist, it is created and linked to the application's root container.
 	GetScope(scopeName string) Container
+
+	// CloseScope stops a scope created by GetScope and removes it from the application.
+	// Its OnPreStop and OnStop hooks run, including those of the transient instances of
+	// ProvideFactory providers it resolved, see also Release. The singletons registered in the app
+	// and first resolved in the scope are left running, their hooks run at Teardown.
+	CloseScope(scopeName string) error
+
+	// Fork creates an overlay app that shares this app's registrations read-only.
+	// Providers added to the fork shadow the parent's without touching them, and lazy
+	// singletons are instantiated again in each fork, so tests can adjust the wiring of
+	// an expensive container in parallel. Hooks already registered on the parent are not
+	// carried over, the fork has its own lifecycle.
+	Fork() App
+
+	// Seal freezes the registrations of the app and all of its scopes. Afterwards AddProvider
+	// returns ErrSealed, and lookups read from an immutable snapshot instead of a sync.Map.
+	// Scopes created after sealing are sealed as well.
+	Seal()
+
+	// Reload reads the reloadable configs again and validates them, swaps them in, then runs
+	// the OnReload hooks of the root and then of the scopes by name, each scope ordered like its
+	// start hooks, see WithPriority. If a config is invalid nothing is swapped; if a hook fails,
+	// the previous configs are restored and the completed hooks run again.
+	// See ReloadOnSignal and ReloadOnFileChange to trigger it.
+	Reload(ctx stdctx.Context) error
+
+	// Run starts the application and blocks until SIGINT or SIGTERM is received, ctx is done,
+	// or a component requests the shutdown. It then runs Teardown, bounded by the shutdown
+	// timeout, see WithShutdownTimeout. The stop hooks see the deadline through StopCtx.Context.
+	// A second signal during teardown cancels it and makes Run return at once with Forced set.
+	// Run does not exit the process itself: after a forced or timed out teardown, the teardown may
+	// still be running, so main must exit with the code of the result:
+	//
+	//	os.Exit(app.Run(context.Background()).ExitCode())
+	Run(ctx stdctx.Context) RunResult
+
+	// Done returns a channel closed once a component requests the shutdown through a Shutdowner.
+	Done() <-chan struct{}
+
+	// Wait blocks until a component requests the shutdown, and reports which one asked and why.
+	Wait() ShutdownRequest
+
+	// Reset returns a terminated or failed app to STATE_UNINITIALIZED. The hooks are dropped,
+	// the lazy singletons are discarded, the configs are bound again from their sources, and the
+	// Invoke calls that succeeded before the first Startup run again, so the constructors register their hooks anew.
+	// The providers they registered are kept, whatever the duplicate policy, and a sealed app is unsealed meanwhile.
+	// The values get the auto hooks of the instance registered now, the one set by Replace included.
+	Reset() error
+
+	// Restart tears the app down if it is running, resets it, then starts it again.
+	// ctx is checked before starting. The teardown errors are returned along with the others.
+	Restart(ctx stdctx.Context) error
+
+	// Subscribe registers an observer of the events of the app and all of its scopes, see Event.
+	// By default fn is called synchronously on the goroutine emitting the event, and must not block;
+	// WithEventBuffer delivers the events from a goroutine instead. It is safe to call from any goroutine,
+	// the returned function removes the observer once its buffered events are delivered,
+	// and must not be called from fn itself when buffered. A panic of fn is recovered and logged.
+	Subscribe(fn func(Event), opts ...SubscribeOption) (unsubscribe func())
 	CurState() LifeState
 }
 
+const defaultShutdownTimeout = 30 * time.Second
+
 type app struct {
 	*Scope
 	subScopes ext.GSyncMap[*Scope]
-	once      sync.Once
+
+	// base is the app this one was forked from, forked caches the providers
+	// copied from it on first lookup.
+	base   *app
+	forked sync.Map
+
+	autoSeal bool
+
+	// drains holds the stop hooks of replaced providers waiting for their drain period.
+	drainMu sync.Mutex
+	drains  map[*drain]struct{}
+
+	// refs holds the live handles created by InjectRef, keyed by refKey.
+	refs sync.Map
+
+	// lifeMu serializes Startup and Teardown. The hook lists are guarded by mu instead,
+	// which is released while a hook runs so that the hook can register others.
+	lifeMu sync.Mutex
+
+	reloadMu sync.Mutex
+
+	shutdown        atomic.Pointer[shutdownState]
+	shutdownTimeout time.Duration
+
+	// invokes holds the Invoke calls made before Startup, replayed by Reset.
+	invokeMu  sync.Mutex
+	invokes   []recordedInvoke
+	replaying atomic.Bool
+
+	events eventBus
 }
 
 func createApp() *app {
-	return &app{
+	ap := &app{
+		shutdownTimeout: defaultShutdownTimeout,
 		Scope: &Scope{
 			Name:  "root",
 			State: STATE_UNINITIALIZED,
@@ -79,110 +185,258 @@ func createApp() *app {
 			},
 		},
 	}
+	ap.root = ap
+	ap.attachShutdown()
+	return ap
+}
+
+func (ap *app) Fork() App {
+	fork := &app{
+		base:            ap,
+		autoSeal:        ap.autoSeal,
+		shutdownTimeout: ap.shutdownTimeout,
+		Scope: &Scope{
+			Name:      ap.Name,
+			State:     STATE_UNINITIALIZED,
+			dupPolicy: ap.dupPolicy,
+			Logger: &loggerWrapper{
+				Level:  ap.Logger.Level,
+				Logger: ap.Logger.Logger,
+			},
+		},
+	}
+	fork.root = fork
+	fork.attachShutdown()
+	return fork
+}
+
+func (ap *app) GetProvider(k string, isNamed bool) (any, bool) {
+	if val, ok := ap.Scope.GetProvider(k, isNamed); ok {
+		return val, ok
+	}
+	if ap.base == nil {
+		return nil, false
+	}
+
+	cacheKey := forkCacheKey(k, isNamed)
+	if val, ok := ap.forked.Load(cacheKey); ok {
+		return val, ok
+	}
+	val, ok := ap.base.GetProvider(k, isNamed)
+	if !ok {
+		return nil, false
+	}
+	// Providers holding an instance are copied so the fork builds its own,
+	// the others are shared as is.
+	if fp, ok := val.(forkable); ok {
+		val, _ = ap.forked.LoadOrStore(cacheKey, fp.fork())
+	}
+	return val, true
 }
 
 func (ap *app) Startup() error {
-	ap.mu.Lock()
-	defer ap.mu.Unlock()
-	if ap.State != STATE_UNINITIALIZED {
+	ap.lifeMu.Lock()
+	defer ap.lifeMu.Unlock()
+	if ap.CurState() != STATE_UNINITIALIZED {
 		return errors.New("The app has been launched.")
 	}
 
-	// Create a context and execute all start hooks.
-	ctx := getContext(ap)
-	defer ctx.recycle()
+	// Execute all start hooks.
 	ap.changeState(STATE_INITIALIZING)
-	if err := ap.start(ctx); err != nil {
+	if err := ap.start(); err != nil {
 		return err
 	}
 	ap.changeState(STATE_READY)
+	if ap.autoSeal {
+		ap.Seal()
+	}
 	return nil
 }
 
 func (ap *app) Teardown() error {
-	ap.mu.Lock()
-	defer ap.mu.Unlock()
+	return ap.teardown(stdctx.Background())
+}
 
-	if ap.State != STATE_READY && ap.State != STATE_INITIALIZING {
+// teardown runs Teardown, passing std to the stop hooks through StopCtx.Context.
+func (ap *app) teardown(std stdctx.Context) error {
+	ap.lifeMu.Lock()
+	defer ap.lifeMu.Unlock()
+
+	if state := ap.CurState(); state != STATE_READY && state != STATE_INITIALIZING {
 		return errors.New("The app has not been launched yet.")
 	}
 
-	ap.Logger.Debug("The app is starting teardown.")
+	ap.Logger.log(LOG_DEBUG, "The app is starting teardown.")
+	// Replaced providers still draining are stopped right away.
+	errs := ap.flushDrains()
 	// Create a context and execute all stop hooks.
 	ctx := getContext(ap)
 	defer ctx.recycle()
-	if err := ap.stop(ctx); err != nil {
+	ctx.std = std
+	errs = append(errs, ap.stop(ctx)...)
+	if err := newLifecycleError("teardown", errs); err != nil {
 		return err
 	}
-	ap.Logger.Debug("The app has been terminated")
+	ap.Logger.log(LOG_DEBUG, "The app has been terminated.")
 	return nil
 }
 
 func (ap *app) SetLogger(l Logger) App {
-	ap.Logger.Logger = l
+	ap.Logger.setLogger(l)
 	return ap
 }
 
 func (ap *app) SetLogLevel(level LogLevel) App {
-	ap.Logger.Level = level
+	ap.Logger.setLevel(level)
 	return ap
 }
 
 func (ap *app) GetScope(scopeName string) Container {
 	s := &Scope{
-		parent: ap,
-		Name:   scopeName,
-		State:  ap.State,
-		Logger: ap.Logger,
+		parent:    ap,
+		root:      ap,
+		Name:      scopeName,
+		State:     ap.State,
+		Logger:    ap.Logger,
+		dupPolicy: ap.dupPolicy,
 	}
 	if _, loaded := ap.subScopes.Swap(scopeName, s); loaded {
-		ap.Logger.Warn("The scope [%s] is overwritten", scopeName)
+		ap.Logger.log(LOG_WARN, "The scope is overwritten.", Field{"scope", scopeName})
+	}
+	// Checked after storing, so a concurrent Seal either ranges over the scope or is seen here.
+	if ap.snapshot.Load() != nil {
+		s.seal()
 	}
 	return s
 }
 
-func (ap *app) start(ctx *context) error {
-	ap.Logger.Debug("The app is starting initialization.")
-
-	for i := range ap.startHooks {
-		if err := ap.startHooks[i](ctx); err != nil {
-			return err
-		}
-	}
+func (ap *app) Seal() {
+	ap.seal()
+	ap.subScopes.Range(func(key string, value *Scope) bool {
+		value.seal()
+		return true
+	})
+}
 
-	var err error
+// rangeScopes calls f for the root scope and then for every sub scope.
+func (ap *app) rangeScopes(f func(sc *Scope)) {
+	f(ap.Scope)
 	ap.subScopes.Range(func(key string, value *Scope) bool {
-		if ferr := value.start(ctx); ferr != nil {
-			err = ferr
-			return false
-		}
+		f(value)
 		return true
 	})
+}
 
-	ap.Logger.Debug("The app is ready.")
-	return err
+func forkCacheKey(k string, isNamed bool) string {
+	if isNamed {
+		return "named:" + k
+	}
+	return "type:" + k
 }
 
-func (ap *app) stop(ctx Context) error {
-	errOccurred := false
-	for i := len(ap.stopHooks) - 1; i >= 0; i-- {
-		if err := ap.stopHooks[i](ctx); err != nil {
-			ap.Logger.Error("Execute stop hook failed, err:", err)
-			errOccurred = true
+func (ap *app) start() error {
+	ap.Logger.log(LOG_DEBUG, "The app is starting initialization.")
+
+	// The owners of the start hooks that completed, the stop hooks of the others are skipped on rollback.
+	started := make(map[*component]struct{})
+	scopes := ap.orderedScopes()
+	for _, sc := range scopes[1:] {
+		sc.changeState(STATE_INITIALIZING)
+	}
+	// Nothing is started if the hooks cannot be ordered.
+	for _, sc := range scopes {
+		if err := sc.checkHookOrder(); err != nil {
+			return ap.rollback(scopes, started, err)
+		}
+	}
+	for _, phase := range startPhases {
+		ap.Logger.log(LOG_DEBUG, "The app is entering a phase.", Field{"phase", phase})
+		for _, sc := range scopes {
+			if err := sc.runStartHooks(ap.scopeContainer(sc), phase, started); err != nil {
+				return ap.rollback(scopes, started, err)
+			}
 		}
 	}
+	for _, sc := range scopes[1:] {
+		sc.changeState(STATE_READY)
+	}
 
-	ap.subScopes.Range(func(key string, value *Scope) bool {
-		if err := value.stop(ctx); err != nil {
-			ap.Logger.Error("Execute scope stop hook failed, err:", err)
-			errOccurred = true
+	ap.Logger.log(LOG_DEBUG, "The app is ready.")
+	return nil
+}
+
+// rollback stops the components started by a failed Startup in reverse order, then moves
+// the app and its scopes to STATE_FAILED. It returns startErr joined with the rollback failures.
+func (ap *app) rollback(scopes []*Scope, started map[*component]struct{}, startErr error) error {
+	ap.Logger.log(LOG_ERROR, "The app failed to start, rolling back.", Field{"error", startErr})
+
+	// Collected now, so the start hooks registered during the startup are included.
+	hasStart := make(map[*component]struct{})
+	for _, sc := range scopes {
+		sc.collectStartOwners(hasStart)
+	}
+	skip := func(owner *component) bool {
+		_, registered := hasStart[owner]
+		_, ok := started[owner]
+		return registered && !ok
+	}
+
+	ctx := getContext(ap)
+	defer ctx.recycle()
+	var errs []*HookError
+	for _, phase := range stopPhases {
+		for i := len(scopes) - 1; i >= 0; i-- {
+			errs = append(errs, scopes[i].runStopHooks(ctx, phase, skip)...)
 		}
-		return true
-	})
+	}
+	for _, sc := range scopes {
+		sc.changeState(STATE_FAILED)
+	}
+	if rerr := newLifecycleError("rollback", errs); rerr != nil {
+		return errors.Join(startErr, rerr)
+	}
+	return startErr
+}
 
-	if errOccurred {
-		return errors.New("errors occurred during stop process, see logs for details")
+// scopeContainer returns the container the hooks of sc resolve through. The root hooks
+// resolve through the app, which also looks up the app it was forked from.
+func (ap *app) scopeContainer(sc *Scope) Container {
+	if sc == ap.Scope {
+		return ap
 	}
+	return sc
+}
 
-	return nil
+// orderedScopes returns the root scope followed by the sub scopes, in the order they are started.
+func (ap *app) orderedScopes() []*Scope {
+	var scopes []*Scope
+	ap.rangeScopes(func(sc *Scope) {
+		scopes = append(scopes, sc)
+	})
+	// The root comes first, the sub scopes are sorted by name so the order does not depend on the map.
+	sort.Slice(scopes[1:], func(i, j int) bool {
+		return scopes[i+1].Name < scopes[j+1].Name
+	})
+	return scopes
+}
+
+// stop runs every stop hook of the app and its scopes, phase by phase, even if some of
+// them fail or panic, and returns the failures.
+func (ap *app) stop(ctx StopCtx) []*HookError {
+	scopes := ap.orderedScopes()
+	for _, sc := range scopes {
+		sc.changeState(STATE_SHUTTING_DOWN)
+	}
+	var errs []*HookError
+	for _, phase := range stopPhases {
+		ap.Logger.log(LOG_DEBUG, "The app is entering a phase.", Field{"phase", phase})
+		// The scopes stop in reverse, like a rollback, so the root singletons they use outlive them.
+		for i := len(scopes) - 1; i >= 0; i-- {
+			errs = append(errs, scopes[i].runStopHooks(ctx, phase, nil)...)
+		}
+	}
+	for _, sc := range scopes {
+		sc.changeState(STATE_TERMINATED)
+	}
+	return errs
 }
@@ -0,0 +1,86 @@
+package gdit
+
+import (
+	stdctx "context"
+	"io"
+)
+
+// Starter is implemented by instances that start themselves. When a provider's instance
+// implements it, its Start method is registered as an OnStart hook, unless the constructor
+// registered an OnStart hook itself, or the provider opted out with WithoutAutoHooks.
+// The hooks of the other start phases, such as OnReady, do not replace it.
+type Starter interface {
+	Start(ctx stdctx.Context) error
+}
+
+// Stopper is implemented by instances that stop themselves. When a provider's instance
+// implements it, its Stop method is registered as an OnStop hook, unless the constructor
+// registered an OnStop hook itself, or the provider opted out with WithoutAutoHooks.
+// An OnPreStop hook does not replace it. An instance implementing io.Closer instead is closed
+// by an OnStop hook under the same rule. Both see the deadline of StopCtx.Context.
+type Stopper interface {
+	Stop(ctx stdctx.Context) error
+}
+
+// registerAutoHooks registers the hooks matching the interfaces implemented by instance.
+// A hook of the phase the constructor already registered by hand is not added, so that an
+// instance is never started or stopped twice.
+func registerAutoHooks(ctx InvokeCtx, instance any) {
+	var hasStart, hasStop bool
+	if c, ok := ctx.(*context); ok {
+		for _, h := range c.startHooks {
+			hasStart = hasStart || h.phase == PHASE_START
+		}
+		for _, h := range c.stopHooks {
+			hasStop = hasStop || h.phase == PHASE_STOP
+		}
+	}
+
+	if s, ok := instance.(Starter); ok && !hasStart {
+		ctx.OnStart(func(startCtx StartCtx) error {
+			return s.Start(stdctx.Background())
+		}, WithHookName("Start"))
+	}
+	if hasStop {
+		return
+	}
+	if s, ok := instance.(Stopper); ok {
+		ctx.OnStop(func(stopCtx StopCtx) error {
+			return s.Stop(stopCtx.Context())
+		}, WithHookName("Stop"))
+	} else if c, ok := instance.(io.Closer); ok {
+		ctx.OnStop(func(stopCtx StopCtx) error {
+			return closeWithin(stopCtx.Context(), c)
+		}, WithHookName("Close"))
+	}
+}
+
+// closeWithin closes c, giving up once ctx is done. Close itself cannot be interrupted,
+// it keeps running in the background.
+func closeWithin(ctx stdctx.Context, c io.Closer) error {
+	if ctx.Done() == nil {
+		return c.Close()
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Close()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// attachAutoHooks registers the auto hooks of a value provider on c, owned by the provider.
+func (p *valueProvider[T]) attachAutoHooks(c Container) error {
+	if !p.autoHooks {
+		return nil
+	}
+	ctx := getContext(c)
+	defer ctx.recycle()
+	ctx.owner = newComponent(p.Name(), p)
+	registerAutoHooks(ctx, p.instance)
+	return ctx.tryAddOrRunHook()
+}
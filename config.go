@@ -0,0 +1,195 @@
+package gdit
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/saweima12/gdit/internal/utils"
+)
+
+// ProvideConfig registers a configuration struct built from layered sources as a value provider.
+// The struct is validated against its `validate` tags when it is first resolved, see validateConfig,
+// and the fields tagged `secret:"true"` are redacted from the container's logs and errors.
+// [sources] -> The sources to read, see FromJSONFile, FromEnv and FromFlags.
+//
+//	T is a struct or a pointer to a struct. Its exported fields are filled in order of precedence,
+//	each layer overriding the previous ones:
+//	  1. the `default` struct tag,
+//	  2. JSON files, in the order given,
+//	  3. environment variables,
+//	  4. flags that were set on the command line.
+//	Fields tagged `validate:"required"` must be non-zero once every layer is applied.
+//
+// Attach reports every missing required field and invalid value in a single *ConfigError.
+// Returns a ConfigBuilder to further configure the provided service.
+func ProvideConfig[T any](sources ...ConfigSource) ConfigBuilder[T] {
+	pb := newProviderBuilder[T](provider_config)
+	pb.bind = func() (T, error) {
+		return bindConfig[T](sources)
+	}
+	return pb
+}
+
+// ConfigBuilder configures a provider registered by ProvideConfig.
+type ConfigBuilder[T any] interface {
+	ProviderBuilder[T]
+	// Reloadable lets App.Reload read the sources again and swap in the new values.
+	Reloadable() ProviderBuilder[T]
+}
+
+// configProvider is a value provider validating its instance on first resolution.
+type configProvider[T any] struct {
+	baseProvider
+	cur        atomic.Pointer[T]
+	bind       func() (T, error)
+	reloadable bool
+	once       sync.Once
+	err        error
+}
+
+func (p *configProvider[T]) Get(ctx InvokeCtx) (T, error) {
+	p.once.Do(func() {
+		p.err = validateConfig(p.name, reflect.ValueOf(p.cur.Load()).Elem())
+	})
+	if p.err != nil {
+		return utils.Empty[T](), p.err
+	}
+	return *p.cur.Load(), nil
+}
+
+func (p *configProvider[T]) kind() string {
+	return "config"
+}
+
+func (p *configProvider[T]) cached() bool {
+	return true
+}
+
+// reloadableConfig is implemented by every configProvider, whatever its type argument.
+type reloadableConfig interface {
+	Key() string
+	IsNamed() bool
+	Name() string
+	isReloadable() bool
+	// rebind reads the sources again and validates the result, without swapping it in.
+	rebind() (next any, err error)
+	// swap stores a value returned by rebind or swap, and returns the previous one.
+	swap(next any) (prev any)
+}
+
+func (p *configProvider[T]) isReloadable() bool {
+	return p.reloadable
+}
+
+func (p *configProvider[T]) rebind() (any, error) {
+	instance, err := p.bind()
+	if err != nil {
+		return nil, err
+	}
+	if err := validateConfig(p.name, reflect.ValueOf(&instance).Elem()); err != nil {
+		return nil, err
+	}
+	return &instance, nil
+}
+
+// resettableConfig is implemented by every configProvider, Reset binds them again.
+type resettableConfig interface {
+	Name() string
+	reset() (any, error)
+}
+
+// reset returns a provider bound again from the sources, validated on its first resolution like the original.
+func (p *configProvider[T]) reset() (any, error) {
+	instance, err := p.bind()
+	if err != nil {
+		return nil, err
+	}
+	next := &configProvider[T]{
+		bind:         p.bind,
+		reloadable:   p.reloadable,
+		baseProvider: p.baseProvider,
+	}
+	next.cur.Store(&instance)
+	return next, nil
+}
+
+func (p *configProvider[T]) swap(next any) any {
+	return p.cur.Swap(next.(*T))
+}
+
+// ConfigError is returned when a configuration cannot be bound.
+type ConfigError struct {
+	Type string
+	// Missing lists the paths of the required fields left empty, such as `Database.Host`.
+	Missing []string
+	// Invalid holds the errors of the sources, such as a value that cannot be parsed.
+	Invalid []error
+}
+
+func (e *ConfigError) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing required fields: "+strings.Join(e.Missing, ", "))
+	}
+	for _, err := range e.Invalid {
+		parts = append(parts, err.Error())
+	}
+	return fmt.Sprintf("The config %s is invalid: %s", e.Type, strings.Join(parts, "; "))
+}
+
+func bindConfig[T any](sources []ConfigSource) (T, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	isPtr := t.Kind() == reflect.Pointer
+	if isPtr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return utils.Empty[T](), fmt.Errorf("The config %s must be a struct or a pointer to a struct.", utils.GetType[T]())
+	}
+
+	target := reflect.New(t).Elem()
+	cerr := &ConfigError{Type: utils.GetType[T]()}
+	if err := applyConfigDefaults(target); err != nil {
+		cerr.Invalid = append(cerr.Invalid, err)
+	}
+
+	ordered := make([]ConfigSource, len(sources))
+	copy(ordered, sources)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].rank() < ordered[j].rank()
+	})
+	for _, src := range ordered {
+		if err := src.apply(target); err != nil {
+			cerr.Invalid = append(cerr.Invalid, err)
+		}
+	}
+
+	walkConfigFields(target, nil, func(field reflect.StructField, path []string, v reflect.Value) error {
+		if hasValidateRule(field, "required") && v.IsZero() {
+			cerr.Missing = append(cerr.Missing, strings.Join(path, "."))
+		}
+		return nil
+	})
+	if len(cerr.Missing) > 0 || len(cerr.Invalid) > 0 {
+		return utils.Empty[T](), cerr
+	}
+
+	if isPtr {
+		return target.Addr().Interface().(T), nil
+	}
+	return target.Interface().(T), nil
+}
+
+// hasValidateRule reports whether the `validate` tag of a field holds the given rule.
+func hasValidateRule(field reflect.StructField, rule string) bool {
+	for _, r := range strings.Split(field.Tag.Get("validate"), ",") {
+		if strings.TrimSpace(r) == rule {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,286 @@
+package gdit
+
+import (
+	"encoding"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Precedence of the configuration sources, a higher rank overrides a lower one.
+const (
+	config_rank_default = iota
+	config_rank_json
+	config_rank_env
+	config_rank_flag
+)
+
+// ConfigSource supplies values for the fields of a configuration struct, see ProvideConfig.
+type ConfigSource interface {
+	rank() int
+	apply(target reflect.Value) error
+}
+
+type jsonFileSource struct {
+	path string
+}
+
+// FromJSONFile reads the configuration from a JSON file. Fields absent from the file keep
+// the value of the sources with a lower precedence.
+func FromJSONFile(path string) ConfigSource {
+	return &jsonFileSource{path: path}
+}
+
+func (src *jsonFileSource) rank() int {
+	return config_rank_json
+}
+
+func (src *jsonFileSource) apply(target reflect.Value) error {
+	data, err := os.ReadFile(src.path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, target.Addr().Interface()); err != nil {
+		return fmt.Errorf("decode %s failed, err: %v", src.path, err)
+	}
+	return nil
+}
+
+type envSource struct {
+	prefix string
+	lookup func(key string) (string, bool)
+}
+
+// FromEnv reads the configuration from environment variables. The variable of a field
+// is named by its `env` tag, or else by the prefix followed by the upper snake case path
+// of the field, so `Database.MaxConns` with the prefix `APP` reads `APP_DATABASE_MAX_CONNS`.
+func FromEnv(prefix string) ConfigSource {
+	return &envSource{prefix: prefix, lookup: os.LookupEnv}
+}
+
+func (src *envSource) rank() int {
+	return config_rank_env
+}
+
+func (src *envSource) apply(target reflect.Value) error {
+	return walkConfigFields(target, nil, func(field reflect.StructField, path []string, v reflect.Value) error {
+		name := field.Tag.Get("env")
+		if name == "-" {
+			return nil
+		}
+		if name == "" {
+			name = envName(src.prefix, path)
+		}
+		raw, ok := src.lookup(name)
+		if !ok {
+			return nil
+		}
+		if err := setConfigField(v, raw); err != nil {
+			return configFieldError(field, path, "env "+name, err)
+		}
+		return nil
+	})
+}
+
+type flagSource struct {
+	fs *flag.FlagSet
+}
+
+// FromFlags reads the configuration from the flags that were set on a parsed FlagSet.
+// The flag of a field is named by its `flag` tag, or else by the lower kebab case path
+// of the field, so `Database.MaxConns` reads `-database.max-conns`.
+// Flags left to their default value do not override the other sources.
+func FromFlags(fs *flag.FlagSet) ConfigSource {
+	return &flagSource{fs: fs}
+}
+
+func (src *flagSource) rank() int {
+	return config_rank_flag
+}
+
+func (src *flagSource) apply(target reflect.Value) error {
+	set := make(map[string]*flag.Flag)
+	src.fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = f
+	})
+	return walkConfigFields(target, nil, func(field reflect.StructField, path []string, v reflect.Value) error {
+		name := field.Tag.Get("flag")
+		if name == "-" {
+			return nil
+		}
+		if name == "" {
+			name = flagName(path)
+		}
+		f, ok := set[name]
+		if !ok {
+			return nil
+		}
+		if err := setConfigField(v, f.Value.String()); err != nil {
+			return configFieldError(field, path, "flag -"+name, err)
+		}
+		return nil
+	})
+}
+
+// applyConfigDefaults sets the fields carrying a `default` tag.
+func applyConfigDefaults(target reflect.Value) error {
+	return walkConfigFields(target, nil, func(field reflect.StructField, path []string, v reflect.Value) error {
+		raw, ok := field.Tag.Lookup("default")
+		if !ok {
+			return nil
+		}
+		if err := setConfigField(v, raw); err != nil {
+			return configFieldError(field, path, fmt.Sprintf("default %q", raw), err)
+		}
+		return nil
+	})
+}
+
+// configFieldError reports a value of a source that cannot be set to the field.
+// The parse errors echo the value, they are left out for a secret field.
+func configFieldError(field reflect.StructField, path []string, source string, err error) error {
+	if isSecretField(field) {
+		if strings.HasPrefix(source, "default ") {
+			source = "default"
+		}
+		return fmt.Errorf("%s: %s: invalid value %s", strings.Join(path, "."), source, redactedValue)
+	}
+	return fmt.Errorf("%s: %s: %v", strings.Join(path, "."), source, err)
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// walkConfigFields calls f for every exported leaf field of a struct, descending
+// into nested structs. path holds the field names from the root struct.
+func walkConfigFields(v reflect.Value, path []string, f func(field reflect.StructField, path []string, v reflect.Value) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldPath := append(path[:len(path):len(path)], field.Name)
+		fv := v.Field(i)
+		if isNestedConfig(field.Type) {
+			if err := walkConfigFields(fv, fieldPath, f); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := f(field, fieldPath, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isNestedConfig(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType && !reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// setConfigField parses raw into the field according to its type.
+func setConfigField(v reflect.Value, raw string) error {
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		// Comma separated items, such as `a, b, c`.
+		parts := strings.Split(raw, ",")
+		if strings.TrimSpace(raw) == "" {
+			parts = nil
+		}
+		slice := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setConfigField(slice.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func envName(prefix string, path []string) string {
+	parts := make([]string, 0, len(path)+1)
+	if prefix != "" {
+		parts = append(parts, prefix)
+	}
+	for _, name := range path {
+		parts = append(parts, strings.ToUpper(splitWords(name, "_")))
+	}
+	return strings.Join(parts, "_")
+}
+
+func flagName(path []string) string {
+	parts := make([]string, len(path))
+	for i, name := range path {
+		parts[i] = strings.ToLower(splitWords(name, "-"))
+	}
+	return strings.Join(parts, ".")
+}
+
+// splitWords separates the words of a camel case name, `MaxConns` -> `Max_Conns`,
+// `HTTPPort` -> `HTTP_Port`.
+func splitWords(name, sep string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				sb.WriteString(sep)
+			}
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
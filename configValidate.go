@@ -0,0 +1,185 @@
+package gdit
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// validateConfig checks the `validate` tag of every field of a configuration struct.
+// The supported rules are separated by commas:
+//
+//	required       the field must not be zero.
+//	min=N, max=N   bounds of a number, or of the length of a string, slice or map.
+//	               Durations take a duration such as `min=1s`.
+//	oneof=a b c    the field must hold one of the space separated values.
+//	pattern=RE     the string must match the regular expression. The rule must come last,
+//	               the rest of the tag, commas included, is the expression.
+//
+// Secret fields are redacted from the reported violations.
+func validateConfig(typeName string, v reflect.Value) error {
+	return validateFields(typeName, v, false)
+}
+
+// validateValue checks a value registered by ProvideValue like a config, but skips the rules it
+// does not know, the tag may be written for another validator, such as `validate:"required,email"`.
+func validateValue(typeName string, v reflect.Value) error {
+	return validateFields(typeName, v, true)
+}
+
+func validateFields(typeName string, v reflect.Value, skipUnknown bool) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return fmt.Errorf("The config %s is nil.", typeName)
+		}
+		v = v.Elem()
+	}
+
+	cerr := &ConfigError{Type: typeName}
+	walkConfigFields(v, nil, func(field reflect.StructField, path []string, fv reflect.Value) error {
+		name := strings.Join(path, ".")
+		for _, rule := range parseValidateRules(field.Tag.Get("validate")) {
+			if rule.name == "required" {
+				if fv.IsZero() {
+					cerr.Missing = append(cerr.Missing, name)
+				}
+				continue
+			}
+			if skipUnknown && !knownValidateRules[rule.name] {
+				continue
+			}
+			if err := checkConfigRule(rule, fv); err != nil {
+				shown := redactedValue
+				if !isSecretField(field) {
+					// The value may nest secret fields of its own.
+					shown = Redact(fv.Interface())
+				}
+				cerr.Invalid = append(cerr.Invalid, fmt.Errorf("%s: %s %v", name, shown, err))
+			}
+		}
+		return nil
+	})
+	if len(cerr.Missing) > 0 || len(cerr.Invalid) > 0 {
+		return cerr
+	}
+	return nil
+}
+
+// hasValidateRules reports whether t, a struct or a pointer to a struct, has a field carrying
+// a `validate` tag, so the values registered by ProvideValue are validated like the configs.
+func hasValidateRules(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if _, ok := field.Tag.Lookup("validate"); ok {
+			return true
+		}
+		if isNestedConfig(field.Type) && hasValidateRules(field.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// knownValidateRules lists the rules checked by checkConfigRule, required aside.
+var knownValidateRules = map[string]bool{"min": true, "max": true, "oneof": true, "pattern": true}
+
+type validateRule struct {
+	name  string
+	param string
+}
+
+func parseValidateRules(tag string) []validateRule {
+	var rules []validateRule
+	for tag != "" {
+		part := tag
+		if strings.HasPrefix(strings.TrimSpace(part), "pattern=") {
+			tag = ""
+		} else if i := strings.IndexByte(tag, ','); i >= 0 {
+			part, tag = tag[:i], tag[i+1:]
+		} else {
+			tag = ""
+		}
+
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, param, _ := strings.Cut(part, "=")
+		rules = append(rules, validateRule{name: name, param: param})
+	}
+	return rules
+}
+
+func checkConfigRule(rule validateRule, v reflect.Value) error {
+	switch rule.name {
+	case "min", "max":
+		n, limit, err := configMeasure(v, rule.param)
+		if err != nil {
+			return err
+		}
+		if rule.name == "min" && n < limit {
+			return fmt.Errorf("is less than the min %s", rule.param)
+		}
+		if rule.name == "max" && n > limit {
+			return fmt.Errorf("is greater than the max %s", rule.param)
+		}
+	case "oneof":
+		cur := fmt.Sprintf("%v", v)
+		for _, option := range strings.Fields(rule.param) {
+			if cur == option {
+				return nil
+			}
+		}
+		return fmt.Errorf("is not one of [%s]", rule.param)
+	case "pattern":
+		re, err := regexp.Compile(rule.param)
+		if err != nil {
+			return fmt.Errorf("has an invalid pattern %q: %v", rule.param, err)
+		}
+		if v.Kind() != reflect.String {
+			return fmt.Errorf("cannot match a pattern against %s", v.Type())
+		}
+		if !re.MatchString(v.String()) {
+			return fmt.Errorf("does not match the pattern %q", rule.param)
+		}
+	default:
+		return fmt.Errorf("has an unknown rule %q", rule.name)
+	}
+	return nil
+}
+
+// configMeasure returns the quantity compared by min and max, and the parsed limit.
+func configMeasure(v reflect.Value, param string) (n float64, limit float64, err error) {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(param)
+		return float64(v.Int()), float64(d), err
+	}
+
+	limit, err = strconv.ParseFloat(param, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("has an invalid limit %q", param)
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), limit, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), limit, nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), limit, nil
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), limit, nil
+	}
+	return 0, 0, fmt.Errorf("cannot be measured, its type is %s", v.Type())
+}
@@ -0,0 +1,186 @@
+package gdit_test
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saweima12/gdit"
+)
+
+type testDatabaseConfig struct {
+	Host     string `validate:"required"`
+	MaxConns int    `default:"10"`
+}
+
+type testAppConfig struct {
+	Name     string        `default:"gdit"`
+	Port     int           `default:"8080" flag:"port"`
+	Timeout  time.Duration `default:"5s"`
+	Tags     []string
+	Debug    bool
+	Token    string `env:"TEST_TOKEN" validate:"required"`
+	Database testDatabaseConfig
+}
+
+func TestProvideConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"Name": "from-json", "Port": 9000, "Database": {"Host": "json-host"}}`), 0o600)
+
+	t.Setenv("APP_DATABASE_MAX_CONNS", "32")
+	t.Setenv("APP_TAGS", "a, b")
+	t.Setenv("APP_PORT", "9100")
+	t.Setenv("TEST_TOKEN", "secret")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("port", 0, "")
+	fs.Bool("debug", false, "")
+	fs.Parse([]string{"-port", "9200"})
+
+	t.Run("The sources should be layered by precedence", func(ct *testing.T) {
+		app := gdit.New()
+		err := gdit.ProvideConfig[*testAppConfig](gdit.FromFlags(fs), gdit.FromEnv("APP"), gdit.FromJSONFile(path)).Attach(app)
+		if err != nil {
+			ct.Fatal(err)
+		}
+
+		cfg, _ := gdit.Invoke(app, func(ctx gdit.InvokeCtx) (*testAppConfig, error) {
+			return gdit.Inject[*testAppConfig](ctx)
+		})
+		if cfg.Name != "from-json" || cfg.Port != 9200 || cfg.Timeout != 5*time.Second || cfg.Debug {
+			ct.Errorf("unexpected config %+v", cfg)
+		}
+		if cfg.Token != "secret" || len(cfg.Tags) != 2 || cfg.Tags[1] != "b" {
+			ct.Errorf("unexpected config %+v", cfg)
+		}
+		if cfg.Database.Host != "json-host" || cfg.Database.MaxConns != 32 {
+			ct.Errorf("unexpected database config %+v", cfg.Database)
+		}
+	})
+
+	t.Run("Every missing required field should be reported at once", func(ct *testing.T) {
+		app := gdit.New()
+		err := gdit.ProvideConfig[testAppConfig](gdit.FromEnv("MISSING")).Attach(app)
+
+		var cerr *gdit.ConfigError
+		if !errors.As(err, &cerr) {
+			ct.Fatalf("unexpected error %v", err)
+		}
+		if len(cerr.Missing) != 1 || cerr.Missing[0] != "Database.Host" {
+			ct.Errorf("unexpected missing fields %v", cerr.Missing)
+		}
+	})
+
+	t.Run("An invalid value should be reported", func(ct *testing.T) {
+		ct.Setenv("INVALID_PORT", "abc")
+		err := gdit.ProvideConfig[testAppConfig](gdit.FromEnv("INVALID")).Attach(gdit.New())
+
+		var cerr *gdit.ConfigError
+		if !errors.As(err, &cerr) || len(cerr.Invalid) != 1 {
+			ct.Fatalf("unexpected error %v", err)
+		}
+	})
+}
+
+type testServerConfig struct {
+	Mode     string        `default:"dev" validate:"oneof=dev prod"`
+	Port     int           `default:"80" validate:"min=1,max=65535"`
+	Timeout  time.Duration `default:"1s" validate:"min=1s"`
+	Password string        `default:"p4ss" secret:"true" validate:"pattern=^[a-z]+$"`
+}
+
+func TestConfigValidation(t *testing.T) {
+	t.Run("A valid config should be resolved", func(ct *testing.T) {
+		ct.Setenv("VALID_PASSWORD", "secret")
+		app := gdit.New()
+		gdit.ProvideConfig[*testServerConfig](gdit.FromEnv("VALID")).Attach(app)
+		_, err := gdit.Invoke(app, func(ctx gdit.InvokeCtx) (*testServerConfig, error) {
+			return gdit.Inject[*testServerConfig](ctx)
+		})
+		if err != nil {
+			ct.Error(err)
+		}
+	})
+
+	t.Run("Every violation should be reported on resolution, without secrets", func(ct *testing.T) {
+		ct.Setenv("BAD_MODE", "staging")
+		ct.Setenv("BAD_PORT", "70000")
+		ct.Setenv("BAD_TIMEOUT", "10ms")
+		app := gdit.New()
+		if err := gdit.ProvideConfig[*testServerConfig](gdit.FromEnv("BAD")).Attach(app); err != nil {
+			ct.Fatal(err)
+		}
+
+		_, err := gdit.Invoke(app, func(ctx gdit.InvokeCtx) (*testServerConfig, error) {
+			return gdit.Inject[*testServerConfig](ctx)
+		})
+		var cerr *gdit.ConfigError
+		if !errors.As(err, &cerr) || len(cerr.Invalid) != 4 {
+			ct.Fatalf("unexpected error %v", err)
+		}
+		if strings.Contains(err.Error(), "p4ss") || !strings.Contains(err.Error(), "[REDACTED]") {
+			ct.Errorf("the secret should be redacted: %v", err)
+		}
+	})
+
+	t.Run("A value with validate tags should be validated on Attach", func(ct *testing.T) {
+		app := gdit.New()
+		err := gdit.ProvideValue[*testServerConfig](&testServerConfig{Mode: "staging", Port: 80, Timeout: time.Second, Password: "P4SS"}).Attach(app)
+		var cerr *gdit.ConfigError
+		if !errors.As(err, &cerr) || len(cerr.Invalid) != 2 || strings.Contains(err.Error(), "P4SS") {
+			ct.Errorf("unexpected error %v", err)
+		}
+	})
+
+	t.Run("A value should ignore the rules written for another validator", func(ct *testing.T) {
+		type testContact struct {
+			Email string `validate:"required,email"`
+		}
+		app := gdit.New()
+		if err := gdit.ProvideValue(testContact{Email: "a@example.com"}).Attach(app); err != nil {
+			ct.Fatal(err)
+		}
+		contact, err := gdit.Invoke(app, func(ctx gdit.InvokeCtx) (testContact, error) {
+			return gdit.Inject[testContact](ctx)
+		})
+		if err != nil || contact.Email != "a@example.com" {
+			ct.Errorf("unexpected value %+v with error %v", contact, err)
+		}
+	})
+
+	t.Run("A secret value that cannot be parsed should not be echoed", func(ct *testing.T) {
+		type secretPort struct {
+			Port int `secret:"true"`
+		}
+		ct.Setenv("SECRET_PORT", "hunter2")
+		err := gdit.ProvideConfig[secretPort](gdit.FromEnv("SECRET")).Attach(gdit.New())
+		if err == nil || strings.Contains(err.Error(), "hunter2") {
+			ct.Errorf("unexpected error %v", err)
+		}
+	})
+}
+
+func TestRedact(t *testing.T) {
+	cfg := &testServerConfig{Mode: "dev", Port: 80, Password: "p4ss"}
+
+	t.Run("The secret field should be redacted", func(ct *testing.T) {
+		if gdit.Redact(cfg) != "&{Mode:dev Port:80 Timeout:0s Password:[REDACTED]}" {
+			ct.Errorf("unexpected output %s", gdit.Redact(cfg))
+		}
+	})
+	t.Run("A value referring to itself should be written once", func(ct *testing.T) {
+		type node struct {
+			Name string
+			Next *node
+		}
+		n := &node{Name: "a"}
+		n.Next = n
+		if gdit.Redact(n) != "&{Name:a Next:<cycle>}" {
+			ct.Errorf("unexpected output %s", gdit.Redact(n))
+		}
+	})
+}
@@ -1,6 +1,8 @@
 package gdit
 
 import (
+	stdctx "context"
+	"fmt"
 	"sync"
 )
 
@@ -14,8 +16,12 @@ func (p *contextPool) Get() *context {
 
 func (p *contextPool) Put(ctx *context) {
 	ctx.container = nil
-	ctx.startHook = nil
-	ctx.stopHook = nil
+	ctx.path = nil
+	ctx.owner = nil
+	ctx.std = nil
+	ctx.startHooks = nil
+	ctx.stopHooks = nil
+	ctx.reloadHooks = nil
 	p.pool.Put(ctx)
 }
 
@@ -34,36 +40,81 @@ func getContext(c Container) *context {
 }
 
 type LifecycleStarter interface {
+	// OnPreStart registers a hook function to be executed before the start hooks, in PHASE_PRE_START.
+	// [f] -> The hook function preparing what the start hooks need, such as warming a cache.
+	// [opts] -> Options of the hook, such as WithHookName.
+	OnPreStart(f StartFunc, opts ...HookOption)
+
 	// OnStart registers a hook function to be executed when the application starts.
 	// [f] -> The hook function to execute during the application's startup process.
+	// [opts] -> Options of the hook, such as WithHookName.
 	// This hook allows for custom initialization logic to be executed as part of the startup sequence.
-	OnStart(f StartFunc)
+	// Every registered hook is kept, they run in registration order.
+	OnStart(f StartFunc, opts ...HookOption)
+
+	// OnReady registers a hook function to be executed once every start hook of the app has completed, in PHASE_READY.
+	// [f] -> The hook function announcing the application is ready, such as flipping a readiness probe.
+	// [opts] -> Options of the hook, such as WithHookName.
+	OnReady(f StartFunc, opts ...HookOption)
 }
 
 type LifecycleStoper interface {
+	// OnPreStop registers a hook function to be executed before the stop hooks, in PHASE_PRE_STOP.
+	// [f] -> The hook function stopping the intake of new work, such as draining the connections.
+	// [opts] -> Options of the hook, such as WithHookName.
+	OnPreStop(f StopFunc, opts ...HookOption)
+
 	// OnStop registers a hook function to be executed when the application stops.
 	// [f] -> The hook function to execute during the application's shutdown process.
+	// [opts] -> Options of the hook, such as WithHookName.
 	// This hook allows for custom cleanup logic to be executed as part of the shutdown sequence.
-	OnStop(f StopFunc)
+	// Every registered hook is kept, they run in reverse registration order.
+	OnStop(f StopFunc, opts ...HookOption)
+}
+
+type LifecycleReloader interface {
+	// OnReload registers a hook function to be executed when the application reloads its configuration.
+	// [f] -> The hook function to execute after the reloadable configs are swapped in, see App.Reload.
+	// [opts] -> Options of the hook, such as WithHookName.
+	// This hook allows the derived state, such as log levels or rate limits, to follow the new configuration.
+	OnReload(f ReloadFunc, opts ...HookOption)
+}
+
+type LifecycleShutdowner interface {
+	// Shutdowner returns a handle to request the shutdown of the application on behalf of the hook's component.
+	// The handle outlives the context, so it can be kept by the goroutines started by the hook.
+	Shutdowner() Shutdowner
 }
 
 type InvokeCtx interface {
 	Context
 	LifecycleStarter
 	LifecycleStoper
+	LifecycleReloader
 }
 
 type StartCtx interface {
 	Context
 	LifecycleStoper
+	LifecycleShutdowner
 }
 
 type StopCtx interface {
 	Context
+	// Context returns the context bounding the teardown. App.Run cancels it once the shutdown
+	// timeout expires or a second signal forces the exit, so a slow hook can give up.
+	// It is never done for Teardown, Release, CloseScope and the stop hooks of a replaced provider.
+	Context() stdctx.Context
+}
+
+type ReloadCtx interface {
+	Context
 }
 
 type Context interface {
-	clone() InvokeCtx
+	clone(name string, owner *component) InvokeCtx
+	resolutionPath() []string
+	getContainer() Container
 	getProvider(key string, isNamed bool) (any, bool)
 	tryAddOrRunHook() error
 	recycle()
@@ -71,43 +122,141 @@ type Context interface {
 
 type context struct {
 	container Container
-	startHook StartFunc
-	stopHook  StopFunc
+	path      []string
+	owner     *component
+	// std bounds the teardown running the stop hooks, see StopCtx.Context.
+	std stdctx.Context
+	// The hooks registered during the invocation, their owner is set by tryAddOrRunHook.
+	startHooks  []startHook
+	stopHooks   []stopHook
+	reloadHooks []reloadHook
+}
+
+func (ctx *context) OnPreStart(f StartFunc, opts ...HookOption) {
+	ctx.addStartHook(PHASE_PRE_START, f, opts)
+}
+
+func (ctx *context) OnStart(f StartFunc, opts ...HookOption) {
+	ctx.addStartHook(PHASE_START, f, opts)
+}
+
+func (ctx *context) OnReady(f StartFunc, opts ...HookOption) {
+	ctx.addStartHook(PHASE_READY, f, opts)
+}
+
+func (ctx *context) OnPreStop(f StopFunc, opts ...HookOption) {
+	ctx.addStopHook(PHASE_PRE_STOP, f, opts)
+}
+
+func (ctx *context) OnStop(f StopFunc, opts ...HookOption) {
+	ctx.addStopHook(PHASE_STOP, f, opts)
+}
+
+func (ctx *context) addStartHook(phase LifecyclePhase, f StartFunc, opts []HookOption) {
+	ctx.startHooks = append(ctx.startHooks, startHook{hookOptions: newHookOptions(opts), phase: phase, fn: f})
 }
 
-func (ctx *context) OnStart(f StartFunc) {
-	ctx.startHook = f
+func (ctx *context) addStopHook(phase LifecyclePhase, f StopFunc, opts []HookOption) {
+	ctx.stopHooks = append(ctx.stopHooks, stopHook{hookOptions: newHookOptions(opts), phase: phase, fn: f})
 }
 
-func (ctx *context) OnStop(f StopFunc) {
-	ctx.stopHook = f
+func (ctx *context) OnReload(f ReloadFunc, opts ...HookOption) {
+	ctx.reloadHooks = append(ctx.reloadHooks, reloadHook{hookOptions: newHookOptions(opts), fn: f})
+}
+
+func (ctx *context) Shutdowner() Shutdowner {
+	component := "invoke"
+	if ctx.owner != nil {
+		component = ctx.owner.name
+	}
+	return &shutdownHandle{state: ctx.container.getScope().root.shutdown.Load(), component: component}
+}
+
+func (ctx *context) Context() stdctx.Context {
+	if ctx.std == nil {
+		return stdctx.Background()
+	}
+	return ctx.std
+}
+
+func (ctx *context) getContainer() Container {
+	return ctx.container
 }
 
 func (ctx *context) getProvider(key string, isNamed bool) (any, bool) {
 	return ctx.container.GetProvider(key, isNamed)
 }
 
-func (ctx *context) clone() InvokeCtx {
+func (ctx *context) clone(name string, owner *component) InvokeCtx {
 	nCtx := ctxPool.Get()
 	nCtx.container = ctx.container
+	nCtx.path = append(ctx.resolutionPath(), name)
+	nCtx.owner = owner
 	return nCtx
 }
 
+func (ctx *context) resolutionPath() []string {
+	// Copy the path so that sibling resolutions never share a backing array.
+	path := make([]string, len(ctx.path), len(ctx.path)+1)
+	copy(path, ctx.path)
+	return path
+}
+
 func (ctx *context) recycle() {
 	ctxPool.Put(ctx)
 }
 
+// runLateStartHooks runs the start hooks registered once the app is ready, phase by phase.
+// The stop hooks registered by tryAddOrRunHook must be registered even so.
+func (ctx *context) runLateStartHooks() error {
+	scope := ctx.container.getScope().Name
+	for _, phase := range startPhases {
+		var hooks []startHook
+		for _, h := range ctx.startHooks {
+			if h.phase == phase {
+				hooks = append(hooks, h)
+			}
+		}
+		hooks, err := orderStartHooks(scope, phase, hooks)
+		if err != nil {
+			return err
+		}
+		for _, h := range hooks {
+			if err := h.fn(ctx); err != nil {
+				if h.name != "" {
+					return fmt.Errorf("The start hook %s failed, err: %w", h.name, err)
+				}
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (ctx *context) tryAddOrRunHook() error {
-	if ctx.startHook != nil {
-		if ctx.container.CurState() == STATE_READY {
-			return ctx.startHook(ctx)
-		} else {
-			ctx.container.addStartHook(ctx.startHook)
+	if ctx.owner == nil {
+		ctx.owner = newComponent("invoke", nil)
+	}
+
+	for i := range ctx.startHooks {
+		ctx.startHooks[i].owner = ctx.owner
+	}
+	if ctx.container.CurState() != STATE_READY {
+		for _, h := range ctx.startHooks {
+			ctx.container.addStartHook(h)
 		}
+	} else if err := ctx.runLateStartHooks(); err != nil {
+		return err
+	}
+
+	for _, h := range ctx.stopHooks {
+		h.owner = ctx.owner
+		ctx.container.addStopHook(h)
 	}
 
-	if ctx.stopHook != nil {
-		ctx.container.addStopHook(ctx.stopHook)
+	for _, h := range ctx.reloadHooks {
+		h.owner = ctx.owner
+		ctx.container.addReloadHook(h)
 	}
 	return nil
 }
@@ -0,0 +1,106 @@
+package gdit
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Release disposes of a transient instance created by a ProvideFactory provider, running its
+// stop hooks right away instead of keeping them until the scope that resolved it is closed.
+// [c] -> Container of the app the instance was resolved in.
+// [instance] -> The instance returned by the injection.
+// Its reload hooks are dropped as well.
+// Returns ErrNotTracked if the instance holds no hooks, or the failures of its stop hooks.
+func Release(c Container, instance any) error {
+	if instance == nil || !reflect.TypeOf(instance).Comparable() {
+		return errors.New("Release requires a comparable instance, such as a pointer.")
+	}
+
+	root := c.getScope().root
+	ctx := getContext(c)
+	defer ctx.recycle()
+
+	found := false
+	var errs []*HookError
+	root.rangeScopes(func(sc *Scope) {
+		reloads := sc.takeReloadHooks(func(h reloadHook) bool {
+			return h.owner != nil && h.owner.instance == instance
+		})
+		hooks := sc.takeInstanceStopHooks(instance)
+		if len(hooks) == 0 && len(reloads) == 0 {
+			return
+		}
+		found = true
+		for _, phase := range stopPhases {
+			var batch []stopHook
+			for _, h := range hooks {
+				if h.phase == phase {
+					batch = append(batch, h)
+				}
+			}
+			batch, err := orderStopHooks(sc.Name, phase, batch)
+			if err != nil {
+				sc.Logger.log(LOG_ERROR, "The stop hooks are run in the default order.", Field{"scope", sc.Name}, Field{"error", err})
+			}
+			for _, h := range batch {
+				if herr := h.run(sc.Name, ctx); herr != nil {
+					errs = append(errs, herr)
+				}
+			}
+		}
+	})
+	if !found {
+		return fmt.Errorf("Release %T failed: %w", instance, ErrNotTracked)
+	}
+	return newLifecycleError("release", errs)
+}
+
+// takeInstanceStopHooks removes and returns the stop hooks owned by a transient instance.
+func (sc *Scope) takeInstanceStopHooks(instance any) []stopHook {
+	return sc.removeStopHooks(func(h stopHook) bool {
+		return h.owner != nil && h.owner.instance == instance
+	})
+}
+
+// CloseScope stops the scope and removes it from the app. Its stop hooks run phase by phase,
+// including those of the transient instances it resolved, so a short-lived scope, such as
+// one per request, does not pile up hooks. The singletons of the app it resolved keep running.
+func (ap *app) CloseScope(scopeName string) error {
+	sc, ok := ap.subScopes.LoadAndDelete(scopeName)
+	if !ok {
+		return fmt.Errorf("The scope [%s] does not exist.", scopeName)
+	}
+
+	// The live handles resolved in the scope go with it.
+	ap.refs.Range(func(k, value any) bool {
+		if k.(refKey).container == Container(sc) {
+			ap.refs.Delete(k)
+		}
+		return true
+	})
+
+	// A singleton of the app first resolved in the scope registered its hooks here,
+	// they go to the app, the instance outlives the scope.
+	inherited := func(owner *component) bool {
+		p, ok := owner.provider.(keyedProvider)
+		return ok && owner.instance == nil && !ownsProvider(sc, p)
+	}
+	for _, h := range sc.removeStopHooks(func(h stopHook) bool { return h.owner != nil && inherited(h.owner) }) {
+		ap.addStopHook(h)
+	}
+	for _, h := range sc.takeReloadHooks(func(h reloadHook) bool { return h.owner != nil && inherited(h.owner) }) {
+		ap.addReloadHook(h)
+	}
+
+	ctx := getContext(sc)
+	defer ctx.recycle()
+	sc.changeState(STATE_SHUTTING_DOWN)
+	var errs []*HookError
+	for _, phase := range stopPhases {
+		errs = append(errs, sc.runStopHooks(ctx, phase, nil)...)
+	}
+	sc.changeState(STATE_TERMINATED)
+	sc.Logger.log(LOG_DEBUG, "The scope is closed.", Field{"scope", sc.Name})
+	return newLifecycleError("close", errs)
+}
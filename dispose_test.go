@@ -0,0 +1,141 @@
+package gdit_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/saweima12/gdit"
+)
+
+type testSession struct {
+	id int
+}
+
+func getSessionApp(rec *testRecorder) gdit.App {
+	app := gdit.New()
+	next := 0
+	gdit.ProvideFactory[*testSession](func(ctx gdit.InvokeCtx) (*testSession, error) {
+		next++
+		session := &testSession{id: next}
+		ctx.OnStop(func(stopCtx gdit.StopCtx) error {
+			rec.record(fmt.Sprintf("close:%d", session.id))
+			return nil
+		})
+		return session, nil
+	}).Attach(app)
+	return app
+}
+
+func injectSession(c gdit.Container) *testSession {
+	session, _ := gdit.Invoke(c, func(ctx gdit.InvokeCtx) (*testSession, error) {
+		return gdit.Inject[*testSession](ctx)
+	})
+	return session
+}
+
+func TestRelease(t *testing.T) {
+	rec := &testRecorder{}
+	app := getSessionApp(rec)
+	app.Startup()
+	first, second := injectSession(app), injectSession(app)
+
+	t.Run("Only the released instance should be stopped", func(ct *testing.T) {
+		if err := gdit.Release(app, first); err != nil {
+			ct.Fatal(err)
+		}
+		if rec.String() != "close:1" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+
+	t.Run("An instance should be released once", func(ct *testing.T) {
+		if err := gdit.Release(app, first); !errors.Is(err, gdit.ErrNotTracked) {
+			ct.Errorf("unexpected error %v", err)
+		}
+	})
+
+	t.Run("A released instance should no longer be reloaded", func(ct *testing.T) {
+		rec := &testRecorder{}
+		app := gdit.New()
+		next := 0
+		gdit.ProvideFactory[*testSession](func(ctx gdit.InvokeCtx) (*testSession, error) {
+			next++
+			session := &testSession{id: next}
+			ctx.OnReload(func(reloadCtx gdit.ReloadCtx) error {
+				rec.record(fmt.Sprintf("reload:%d", session.id))
+				return nil
+			})
+			return session, nil
+		}).Attach(app)
+		app.Startup()
+		defer app.Teardown()
+		first, _ := injectSession(app), injectSession(app)
+
+		if err := gdit.Release(app, first); err != nil {
+			ct.Fatal(err)
+		}
+		app.Reload(context.Background())
+		if rec.String() != "reload:2" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+
+	t.Run("The remaining instances should be stopped at teardown", func(ct *testing.T) {
+		app.Teardown()
+		if rec.String() != fmt.Sprintf("close:1,close:%d", second.id) {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+}
+
+func TestCloseScope(t *testing.T) {
+	rec := &testRecorder{}
+	app := getSessionApp(rec)
+	app.Startup()
+	injectSession(app)
+
+	req := app.GetScope("request")
+	injectSession(req)
+	injectSession(req)
+
+	t.Run("Closing the scope should stop the instances it resolved", func(ct *testing.T) {
+		if err := app.CloseScope("request"); err != nil {
+			ct.Fatal(err)
+		}
+		if rec.String() != "close:3,close:2" || req.CurState() != gdit.STATE_TERMINATED {
+			ct.Errorf("unexpected events %s in state %v", rec, req.CurState())
+		}
+	})
+
+	t.Run("A closed scope should not be stopped again", func(ct *testing.T) {
+		if app.CloseScope("request") == nil {
+			ct.Fail()
+		}
+		app.Teardown()
+		if rec.String() != "close:3,close:2,close:1" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+	t.Run("The singletons of the app should outlive the scope", func(ct *testing.T) {
+		rec := &testRecorder{}
+		app := gdit.New()
+		gdit.Provide(func(ctx gdit.InvokeCtx) (*testWorker, error) {
+			return &testWorker{rec: rec, name: "db"}, nil
+		}).Attach(app)
+		app.Startup()
+		req := app.GetScope("request")
+		gdit.InvokeFunc(req, func(ctx gdit.InvokeCtx) error {
+			_, err := gdit.Inject[*testWorker](ctx)
+			return err
+		})
+		if err := app.CloseScope("request"); err != nil || rec.String() != "start:db" {
+			ct.Errorf("unexpected error %v with events %s", err, rec)
+		}
+		app.Teardown()
+		if rec.String() != "start:db,stop:db" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+}
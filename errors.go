@@ -0,0 +1,155 @@
+package gdit
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrSealed is returned when a provider is registered in a sealed container.
+var ErrSealed = errors.New("the container is sealed")
+
+// ErrNotTracked is returned by Release for an instance that holds no hooks, such as a transient
+// instance without stop hooks or one released already. Callers may ignore it with errors.Is.
+var ErrNotTracked = errors.New("the instance holds no hooks")
+
+// ErrReserved is returned when a provider is registered under a key the app provides itself, such as Shutdowner.
+var ErrReserved = errors.New("the key is reserved by the app")
+
+// ResolveError is returned when a dependency cannot be resolved.
+// Path holds the chain of dependencies that were being resolved, from the outermost
+// request down to the dependency that failed.
+type ResolveError struct {
+	Path []string
+	Err  error
+}
+
+func (e *ResolveError) Error() string {
+	return fmt.Sprintf("%v (resolution path: %s)", e.Err, e.PathString())
+}
+
+func (e *ResolveError) Unwrap() error {
+	return e.Err
+}
+
+// PathString returns the resolution path formatted as `A -> B -> C`.
+func (e *ResolveError) PathString() string {
+	return strings.Join(e.Path, " -> ")
+}
+
+func newResolveError(ctx Context, key string, err error) *ResolveError {
+	return &ResolveError{
+		Path: append(ctx.resolutionPath(), key),
+		Err:  err,
+	}
+}
+
+// DuplicateError is returned by AddProvider when a provider is registered under a key
+// that already holds one and the container's policy is DUPLICATE_ERROR.
+type DuplicateError struct {
+	Scope string
+	Name  string
+	// First and Second are the `file:line` locations of both registrations.
+	First  string
+	Second string
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("[%s] -> The provider [%s] is already registered at %s, registered again at %s.",
+		e.Scope, e.Name, e.First, e.Second)
+}
+
+// HookError reports a lifecycle hook that failed or panicked.
+type HookError struct {
+	Scope string
+	// Owner is the name of the provider that registered the hook, or `invoke` for an Invoke call.
+	Owner string
+	// Hook is the name given by WithHookName, empty for an unnamed hook.
+	Hook     string
+	Phase    LifecyclePhase
+	Err      error
+	Panicked bool
+}
+
+func (e *HookError) Error() string {
+	label := e.Owner
+	if e.Hook != "" {
+		label = fmt.Sprintf("%s(%s)", e.Owner, e.Hook)
+	}
+	if e.Panicked {
+		return fmt.Sprintf("[%s] -> The %v hook of %s panicked: %v", e.Scope, e.Phase, label, e.Err)
+	}
+	return fmt.Sprintf("[%s] -> The %v hook of %s failed, err: %v", e.Scope, e.Phase, label, e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// fields returns the log attributes of the failure.
+func (e *HookError) fields() []Field {
+	return []Field{
+		{"scope", e.Scope},
+		{"owner", e.Owner},
+		{"hook", e.Hook},
+		{"phase", e.Phase},
+		{"error", e.Err},
+		{"panicked", e.Panicked},
+	}
+}
+
+// LifecycleError aggregates the hooks that failed during a lifecycle operation, such as
+// Teardown. Every hook is run regardless of the failures of the previous ones.
+type LifecycleError struct {
+	// Op names the operation, such as `teardown` or `rollback`.
+	Op     string
+	Errors []*HookError
+}
+
+func (e *LifecycleError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "The %s failed with %d error(s):", e.Op, len(e.Errors))
+	for _, herr := range e.Errors {
+		sb.WriteString("\n\t")
+		sb.WriteString(herr.Error())
+	}
+	return sb.String()
+}
+
+// Unwrap returns the hook errors, so errors.Is and errors.As look into each of them.
+func (e *LifecycleError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, herr := range e.Errors {
+		errs[i] = herr
+	}
+	return errs
+}
+
+// newLifecycleError returns nil when no hook failed, so the result can be returned as is.
+func newLifecycleError(op string, errs []*HookError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &LifecycleError{Op: op, Errors: errs}
+}
+
+// HookCycleError is returned when the After and Before constraints of the hooks of a phase form a cycle.
+type HookCycleError struct {
+	Scope string
+	Phase LifecyclePhase
+	// Hooks lists the hooks of the cycle, the first one repeated at the end.
+	Hooks []string
+}
+
+func (e *HookCycleError) Error() string {
+	return fmt.Sprintf("[%s] -> The %v hooks cannot be ordered, they form a cycle: %s",
+		e.Scope, e.Phase, strings.Join(e.Hooks, " -> "))
+}
+
+func newHookCycleError(scope string, phase LifecyclePhase, cycle []int, label func(i int) string) *HookCycleError {
+	hooks := make([]string, len(cycle))
+	for i, idx := range cycle {
+		hooks[i] = label(idx)
+	}
+	return &HookCycleError{Scope: scope, Phase: phase, Hooks: hooks}
+}
@@ -0,0 +1,222 @@
+package gdit
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event is emitted by the container to the observers registered with App.Subscribe.
+// It is one of StateChanged, ProviderRegistered, ProviderResolved, HookStarted, HookFinished or HookFailed.
+type Event interface {
+	event()
+}
+
+// StateChanged is emitted when a scope moves from a LifeState to another, the root scope included.
+type StateChanged struct {
+	Scope string
+	From  LifeState
+	To    LifeState
+}
+
+// ProviderRegistered is emitted when a provider is stored in a scope.
+type ProviderRegistered struct {
+	Scope string
+	// Name is the readable form of the key, such as `*Repo` or `primary(*sql.DB)`.
+	Name   string
+	Named  bool
+	Source string
+}
+
+// ProviderResolved is emitted once a dependency has been resolved, or has failed to.
+type ProviderResolved struct {
+	Scope    string
+	Name     string
+	Duration time.Duration
+	// Cached reports that the provider returned the instance it already held,
+	// such as an instantiated lazy singleton or a value.
+	Cached bool
+	Err    error
+}
+
+// HookStarted is emitted before a start, stop or reload hook runs.
+type HookStarted struct {
+	Scope string
+	Owner string
+	Hook  string
+	Phase LifecyclePhase
+}
+
+// HookFinished is emitted once a start, stop or reload hook has returned without error.
+type HookFinished struct {
+	Scope    string
+	Owner    string
+	Hook     string
+	Phase    LifecyclePhase
+	Duration time.Duration
+}
+
+// HookFailed is emitted when a start, stop or reload hook returns an error or panics.
+type HookFailed struct {
+	Scope    string
+	Owner    string
+	Hook     string
+	Phase    LifecyclePhase
+	Duration time.Duration
+	Err      error
+	Panicked bool
+}
+
+func (StateChanged) event()       {}
+func (ProviderRegistered) event() {}
+func (ProviderResolved) event()   {}
+func (HookStarted) event()        {}
+func (HookFinished) event()       {}
+func (HookFailed) event()         {}
+
+// SubscribeOption configures an observer registered by App.Subscribe.
+type SubscribeOption func(sub *subscriber)
+
+// WithEventBuffer delivers the events from a goroutine of the observer, through a buffer of the given size,
+// so a slow observer does not hold up the container. When the buffer is full the event is dropped with a warning.
+// By default the observer is called synchronously, on the goroutine emitting the event.
+func WithEventBuffer(size int) SubscribeOption {
+	return func(sub *subscriber) {
+		sub.buffer = size
+	}
+}
+
+type subscriber struct {
+	fn     func(Event)
+	buffer int
+	ch     chan Event
+	// mu guards ch against the sends racing the unsubscription.
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+func (sub *subscriber) deliver(ev Event, logger *loggerWrapper) {
+	if sub.ch == nil {
+		sub.call(ev, logger)
+		return
+	}
+	sub.mu.RLock()
+	defer sub.mu.RUnlock()
+	if sub.closed {
+		return
+	}
+	select {
+	case sub.ch <- ev:
+	default:
+		logger.log(LOG_WARN, "The event is dropped, the buffer of the observer is full.", Field{"event", fmt.Sprintf("%T", ev)})
+	}
+}
+
+func (sub *subscriber) loop(logger *loggerWrapper) {
+	defer close(sub.done)
+	for ev := range sub.ch {
+		sub.call(ev, logger)
+	}
+}
+
+// call runs the observer and recovers from a panic, so a faulty observer neither crashes
+// the container while it holds its locks, nor stops the delivery of the next events.
+func (sub *subscriber) call(ev Event, logger *loggerWrapper) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.log(LOG_ERROR, "The observer panicked.", Field{"event", fmt.Sprintf("%T", ev)}, Field{"error", r})
+		}
+	}()
+	sub.fn(ev)
+}
+
+// eventBus fans the events of an app out to its observers. The list of observers is copied on write,
+// so emitting only loads a pointer.
+type eventBus struct {
+	mu   sync.Mutex
+	subs atomic.Pointer[[]*subscriber]
+}
+
+// active reports whether anyone observes the events, so the emitters can skip building them.
+func (bus *eventBus) active() bool {
+	subs := bus.subs.Load()
+	return subs != nil && len(*subs) > 0
+}
+
+func (bus *eventBus) emit(ev Event, logger *loggerWrapper) {
+	subs := bus.subs.Load()
+	if subs == nil {
+		return
+	}
+	for _, sub := range *subs {
+		sub.deliver(ev, logger)
+	}
+}
+
+func (bus *eventBus) subscribe(sub *subscriber) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	var subs []*subscriber
+	if cur := bus.subs.Load(); cur != nil {
+		subs = append(subs, *cur...)
+	}
+	subs = append(subs, sub)
+	bus.subs.Store(&subs)
+}
+
+func (bus *eventBus) unsubscribe(sub *subscriber) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	cur := bus.subs.Load()
+	if cur == nil {
+		return
+	}
+	subs := make([]*subscriber, 0, len(*cur))
+	for _, s := range *cur {
+		if s != sub {
+			subs = append(subs, s)
+		}
+	}
+	bus.subs.Store(&subs)
+}
+
+func (ap *app) Subscribe(fn func(Event), opts ...SubscribeOption) func() {
+	sub := &subscriber{fn: fn}
+	for _, opt := range opts {
+		opt(sub)
+	}
+	if sub.buffer > 0 {
+		sub.ch = make(chan Event, sub.buffer)
+		sub.done = make(chan struct{})
+		go sub.loop(ap.Logger)
+	}
+	ap.events.subscribe(sub)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ap.events.unsubscribe(sub)
+			if sub.ch == nil {
+				return
+			}
+			sub.mu.Lock()
+			sub.closed = true
+			close(sub.ch)
+			sub.mu.Unlock()
+			// The buffered events are delivered before returning.
+			<-sub.done
+		})
+	}
+}
+
+// emit sends an event to the observers of the app the scope belongs to.
+func (sc *Scope) emit(ev Event) {
+	sc.root.events.emit(ev, sc.Logger)
+}
+
+// observed reports whether the app the scope belongs to has observers.
+func (sc *Scope) observed() bool {
+	return sc.root != nil && sc.root.events.active()
+}
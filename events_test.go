@@ -0,0 +1,143 @@
+package gdit_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/saweima12/gdit"
+)
+
+type testObserver struct {
+	mu     sync.Mutex
+	events []gdit.Event
+}
+
+func (o *testObserver) observe(ev gdit.Event) {
+	o.mu.Lock()
+	o.events = append(o.events, ev)
+	o.mu.Unlock()
+}
+
+// String lists the events, leaving out the durations.
+func (o *testObserver) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	rec := &testRecorder{}
+	for _, ev := range o.events {
+		switch ev := ev.(type) {
+		case gdit.StateChanged:
+			rec.record(fmt.Sprintf("state:%s:%v", ev.Scope, ev.To))
+		case gdit.ProviderRegistered:
+			rec.record("register:" + ev.Name)
+		case gdit.ProviderResolved:
+			rec.record(fmt.Sprintf("resolve:%s:%v", ev.Name, ev.Cached))
+		case gdit.HookStarted:
+			rec.record(fmt.Sprintf("hook:%v:%s", ev.Phase, ev.Owner))
+		case gdit.HookFinished:
+			rec.record(fmt.Sprintf("done:%v:%s", ev.Phase, ev.Owner))
+		case gdit.HookFailed:
+			rec.record(fmt.Sprintf("failed:%v:%s:%v", ev.Phase, ev.Owner, ev.Err))
+		}
+	}
+	return rec.String()
+}
+
+type testClock struct{}
+
+func TestSubscribe(t *testing.T) {
+	t.Run("The container should emit its events synchronously", func(ct *testing.T) {
+		obs := &testObserver{}
+		app := gdit.New()
+		app.Subscribe(obs.observe)
+		gdit.Provide[*testClock](func(ctx gdit.InvokeCtx) (*testClock, error) {
+			ctx.OnStop(func(stopCtx gdit.StopCtx) error {
+				return errors.New("clock stop failed")
+			})
+			return &testClock{}, nil
+		}).Attach(app)
+		gdit.InvokeFunc(app, func(ctx gdit.InvokeCtx) error {
+			gdit.MustInject[*testClock](ctx)
+			gdit.MustInject[*testClock](ctx)
+			return nil
+		})
+		app.Startup()
+		app.Teardown()
+
+		expected := "register:*gdit_test.testClock,resolve:*gdit_test.testClock:false,resolve:*gdit_test.testClock:true," +
+			"state:root:INITIALIZING,state:root:READY,state:root:SHUTTING_DOWN," +
+			"hook:Stop:*gdit_test.testClock,failed:Stop:*gdit_test.testClock:clock stop failed,state:root:TERMINATED"
+		if obs.String() != expected {
+			ct.Errorf("unexpected events %s", obs)
+		}
+	})
+
+	t.Run("The buffered observer should receive every event before unsubscribing returns", func(ct *testing.T) {
+		obs := &testObserver{}
+		app := gdit.New()
+		unsubscribe := app.Subscribe(obs.observe, gdit.WithEventBuffer(16))
+		addTestComponent(app, &testRecorder{}, "c1", false, false)
+		app.Startup()
+		unsubscribe()
+		app.Teardown()
+
+		expected := "state:root:INITIALIZING,hook:Start:invoke,done:Start:invoke,state:root:READY"
+		if obs.String() != expected {
+			ct.Errorf("unexpected events %s", obs)
+		}
+	})
+
+	t.Run("Observers should be added and removed from any goroutine", func(ct *testing.T) {
+		app := gdit.New()
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				obs := &testObserver{}
+				unsubscribe := app.Subscribe(obs.observe, gdit.WithEventBuffer(4))
+				gdit.ProvideValue[*testClock](&testClock{}).Attach(app.GetScope("worker"))
+				unsubscribe()
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("The reload hooks should be observed", func(ct *testing.T) {
+		app := gdit.New()
+		gdit.InvokeFunc(app, func(ctx gdit.InvokeCtx) error {
+			ctx.OnReload(func(reloadCtx gdit.ReloadCtx) error {
+				return errors.New("boom")
+			})
+			return nil
+		})
+		app.Startup()
+		defer app.Teardown()
+		obs := &testObserver{}
+		app.Subscribe(obs.observe)
+		app.Reload(context.Background())
+
+		if obs.String() != "hook:Reload:invoke,failed:Reload:invoke:boom" {
+			ct.Errorf("unexpected events %s", obs)
+		}
+	})
+
+	t.Run("A panicking observer should not crash the container", func(ct *testing.T) {
+		for _, opts := range [][]gdit.SubscribeOption{nil, {gdit.WithEventBuffer(4)}} {
+			app := gdit.New()
+			unsubscribe := app.Subscribe(func(ev gdit.Event) {
+				panic("observer")
+			}, opts...)
+			addTestComponent(app, &testRecorder{}, "c1", false, false)
+			if err := app.Startup(); err != nil {
+				ct.Fatal(err)
+			}
+			unsubscribe()
+			if err := app.Teardown(); err != nil || app.CurState() != gdit.STATE_TERMINATED {
+				ct.Errorf("unexpected error %v in state %v", err, app.CurState())
+			}
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package gdit_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/saweima12/gdit"
+)
+
+func TestFork(t *testing.T) {
+	parent := getTestApp()
+	parentServ, _ := gdit.Invoke(parent, func(ctx gdit.InvokeCtx) (TestService, error) {
+		return gdit.InjectNamed[TestService](ctx, "TestService")
+	})
+
+	for i := 0; i < 4; i++ {
+		domain := fmt.Sprintf("http://fork%d.example.com", i)
+		t.Run("The fork should have its own wiring", func(ct *testing.T) {
+			ct.Parallel()
+
+			fork := parent.Fork()
+			gdit.ProvideValue[*testConfig](&testConfig{DomainUrl: domain}).Attach(fork)
+
+			gdit.InvokeFunc(fork, func(ctx gdit.InvokeCtx) error {
+				cfg := gdit.MustInject[*testConfig](ctx)
+				if cfg.DomainUrl != domain {
+					ct.Errorf("unexpected domain %s", cfg.DomainUrl)
+				}
+
+				serv := gdit.MustInjectNamed[TestService](ctx, "TestService")
+				if serv == parentServ || serv != gdit.MustInjectNamed[TestService](ctx, "TestService") {
+					ct.Error("the lazy singleton should be instantiated once per fork")
+				}
+				return nil
+			})
+		})
+	}
+
+	t.Run("The parent should keep its own registrations", func(ct *testing.T) {
+		gdit.InvokeFunc(parent, func(ctx gdit.InvokeCtx) error {
+			if gdit.MustInject[*testConfig](ctx).DomainUrl != "http://example.com" {
+				ct.Fail()
+			}
+			if gdit.MustInjectNamed[TestService](ctx, "TestService") != parentServ {
+				ct.Fail()
+			}
+			return nil
+		})
+	})
+	t.Run("The reload hooks of the fork should resolve from the parent", func(ct *testing.T) {
+		fork := parent.Fork()
+		var serv TestService
+		gdit.InvokeFunc(fork, func(ctx gdit.InvokeCtx) error {
+			ctx.OnReload(func(reloadCtx gdit.ReloadCtx) error {
+				var err error
+				serv, err = gdit.InjectNamed[TestService](reloadCtx, "TestService")
+				return err
+			})
+			return nil
+		})
+		fork.Startup()
+		defer fork.Teardown()
+		if err := fork.Reload(context.Background()); err != nil || serv == nil {
+			ct.Errorf("unexpected error %v", err)
+		}
+	})
+}
@@ -1,7 +1,9 @@
 package gdit
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/saweima12/gdit/internal/utils"
 )
@@ -9,16 +11,20 @@ import (
 // New creates and returns a new instance of the application with a root container.
 // This instance is ready for configuration and startup, allowing service registration,
 // lifecycle management, and dependency injection.
-func New() App {
-	return createApp()
+// [opts] -> Options adjusting the behavior of the container, such as WithDuplicatePolicy.
+func New(opts ...Option) App {
+	ap := createApp()
+	for _, opt := range opts {
+		opt(ap)
+	}
+	return ap
 }
 
 // Inject resolves a dependency of type T using the provided context.
 // [ctx] -> The context used for dependency resolution, managing service lifecycles and dependencies.
 // Returns an instance of type T and any error encountered during resolution.
 func Inject[T any](ctx Context) (T, error) {
-	typeStr := utils.GetType[T]()
-	return injectInternal[T](ctx, typeStr, false)
+	return injectInternal[T](ctx, utils.GetTypeKey[T](), utils.GetType[T](), false)
 }
 
 // InjectNamed resolves a named dependency of type T using the provided context.
@@ -26,15 +32,26 @@ func Inject[T any](ctx Context) (T, error) {
 // [name] -> The unique name identifying the dependency to be resolved.
 // Returns an instance of type T associated with the given name and any error encountered.
 func InjectNamed[T any](ctx Context, name string) (T, error) {
-	return injectInternal[T](ctx, name, true)
+	return injectInternal[T](ctx, utils.GetNamedKey[T](name), utils.GetNamedType[T](name), true)
+}
+
+// InjectKey resolves the dependency registered under a typed key.
+// [ctx] -> The context used for dependency resolution.
+// [key] -> The typed key created by NewKey, carrying both the type and the name of the dependency.
+// A key with an empty name resolves the provider registered by type, as WithKey registers it.
+// Returns an instance of type T associated with the given key and any error encountered.
+func InjectKey[T any](ctx Context, key Key[T]) (T, error) {
+	if key.name == "" {
+		return Inject[T](ctx)
+	}
+	return InjectNamed[T](ctx, key.name)
 }
 
 // MustInject resolves a dependency of type T using the provided context. Panics if resolution fails.
 // [ctx] -> The context used for dependency resolution.
 // Returns an instance of type T. Panics with an error message if the dependency cannot be resolved.
 func MustInject[T any](ctx Context) T {
-	typeStr := utils.GetType[T]()
-	item, err := injectInternal[T](ctx, typeStr, false)
+	item, err := injectInternal[T](ctx, utils.GetTypeKey[T](), utils.GetType[T](), false)
 	if err != nil {
 		panic(fmt.Sprintf("MustInejct failed, err: %v", err))
 	}
@@ -46,18 +63,91 @@ func MustInject[T any](ctx Context) T {
 // [name] -> The unique name identifying the dependency to be resolved.
 // Returns an instance of type T associated with the given name. Panics with an error message if the dependency cannot be resolved.
 func MustInjectNamed[T any](ctx Context, name string) T {
-	item, err := injectInternal[T](ctx, name, true)
+	item, err := injectInternal[T](ctx, utils.GetNamedKey[T](name), utils.GetNamedType[T](name), true)
 	if err != nil {
 		panic(fmt.Sprintf("MustInejctNamed failed, err: %v", err))
 	}
 	return item
 }
 
+// MustInjectKey resolves the dependency registered under a typed key. Panics if resolution fails.
+// [ctx] -> The context used for dependency resolution.
+// [key] -> The typed key created by NewKey.
+// Returns an instance of type T associated with the given key. Panics with an error message if the dependency cannot be resolved.
+func MustInjectKey[T any](ctx Context, key Key[T]) T {
+	if key.name == "" {
+		return MustInject[T](ctx)
+	}
+	return MustInjectNamed[T](ctx, key.name)
+}
+
+// InjectGroup resolves every provider of type T registered in the same container
+// under DUPLICATE_GROUP, in registration order. A single provider resolves to a group of one.
+// [ctx] -> The context used for dependency resolution.
+// Returns the instances of type T and any error encountered during resolution.
+func InjectGroup[T any](ctx Context) ([]T, error) {
+	return injectGroupInternal[T](ctx, utils.GetTypeKey[T](), utils.GetType[T](), false)
+}
+
+// InjectNamedGroup resolves every provider of type T registered with the given name
+// under DUPLICATE_GROUP, in registration order.
+// [ctx] -> The context used for dependency resolution.
+// [name] -> The name shared by the grouped dependencies.
+// Returns the instances of type T and any error encountered during resolution.
+func InjectNamedGroup[T any](ctx Context, name string) ([]T, error) {
+	return injectGroupInternal[T](ctx, utils.GetNamedKey[T](name), utils.GetNamedType[T](name), true)
+}
+
 // Invoke calls a constructor function with the container's context, used for service initialization.
 // [c] -> Container where the function is executed, managing service lifecycles and dependencies.
 // [f] -> Constructor function that accepts a Context and returns a service instance (of type T) and an error.
 // Returns the service instance and any error encountered during execution.
 func Invoke[T any](c Container, f func(InvokeCtx) (T, error)) (T, error) {
+	mark := invokeMark(c)
+	resp, err := invokeInternal(c, f)
+	if err == nil {
+		recordInvoke(c, mark, func() error {
+			_, err := invokeInternal(c, f)
+			return err
+		})
+	}
+	return resp, err
+}
+
+// InvokeProvide combines service initialization with automatic registration in the container.
+// [c] -> Container where the function is executed, managing service lifecycles and dependencies.
+// [f] -> Constructor function that accepts a Context and returns a service instance (of type T) and an error.
+// Returns the service instance and any error encountered during execution.
+func InvokeProvide[T any](c Container, f func(InvokeCtx) (T, error)) (T, error) {
+	source := registrationSource()
+	mark := invokeMark(c)
+	resp, err := invokeProvideInternal(c, f, source, false)
+	if err == nil {
+		recordInvoke(c, mark, func() error {
+			// The provider registered by the first run is replaced, whatever the duplicate policy.
+			_, err := invokeProvideInternal(c, f, source, true)
+			return err
+		})
+	}
+	return resp, err
+}
+
+// InvokeFunc executes a function within the container's context, for initialization tasks.
+// [c] -> Container in which the function is executed.
+// [f] -> Function that takes a Context and performs initialization, returning an error if it fails.
+// Returns an error if the initialization task fails.
+func InvokeFunc(c Container, f func(InvokeCtx) error) error {
+	mark := invokeMark(c)
+	err := invokeFuncInternal(c, f)
+	if err == nil {
+		recordInvoke(c, mark, func() error {
+			return invokeFuncInternal(c, f)
+		})
+	}
+	return err
+}
+
+func invokeInternal[T any](c Container, f func(InvokeCtx) (T, error)) (T, error) {
 	ctx := getContext(c)
 	defer ctx.recycle()
 
@@ -71,24 +161,39 @@ func Invoke[T any](c Container, f func(InvokeCtx) (T, error)) (T, error) {
 	return resp, nil
 }
 
-// InvokeProvide combines service initialization with automatic registration in the container.
-// [c] -> Container where the function is executed, managing service lifecycles and dependencies.
-// [f] -> Constructor function that accepts a Context and returns a service instance (of type T) and an error.
-// Returns the service instance and any error encountered during execution.
-func InvokeProvide[T any](c Container, f func(InvokeCtx) (T, error)) (T, error) {
-	instance, err := Invoke[T](c, f)
+func invokeProvideInternal[T any](c Container, f func(InvokeCtx) (T, error), source string, replace bool) (T, error) {
+	ctx := getContext(c)
+	defer ctx.recycle()
+
+	resp, err := f(ctx)
 	if err != nil {
-		return instance, err
+		return resp, err
 	}
-	ProvideValue[T](instance).Attach(c)
-	return instance, nil
+
+	// Build the provider first, so the hooks registered by f are owned by it.
+	pb := newProviderBuilder[T](provider_value)
+	pb.instance = resp
+	p := pb.getProvider(source)
+	ctx.owner = newComponent(p.Name(), p)
+
+	// The hooks are registered once the provider is, a rejected instance is never started.
+	stored := true
+	if replace {
+		c.getScope().replaceProvider(p.Key(), p, p.IsNamed())
+	} else if stored, err = c.getScope().addProvider(p.Key(), p, p.IsNamed()); err != nil {
+		return resp, err
+	}
+	// The instance ignored by the duplicate policy cannot be resolved, only the hooks of f are kept.
+	if stored {
+		registerAutoHooks(ctx, resp)
+	}
+	if err := ctx.tryAddOrRunHook(); err != nil {
+		return resp, err
+	}
+	return resp, nil
 }
 
-// InvokeFunc executes a function within the container's context, for initialization tasks.
-// [c] -> Container in which the function is executed.
-// [f] -> Function that takes a Context and performs initialization, returning an error if it fails.
-// Returns an error if the initialization task fails.
-func InvokeFunc(c Container, f func(InvokeCtx) error) error {
+func invokeFuncInternal(c Container, f func(InvokeCtx) error) error {
 	ctx := getContext(c)
 	defer ctx.recycle()
 	if err := f(ctx); err != nil {
@@ -126,6 +231,11 @@ func ProvideFactory[T any](f func(InvokeCtx) (T, error)) ProviderBuilder[T] {
 
 // ProvideValue registers a pre-instantiated service instance within the DI system.
 // [item] -> The pre-instantiated service instance of type T to be registered.
+//
+//	A struct carrying `validate` tags is validated by Attach like a ProvideConfig struct,
+//	and its fields tagged `secret:"true"` are redacted from the reported violations.
+//	The rules unknown to gdit, such as `email`, are left to the validator they are written for.
+//
 // Returns a ProviderBuilder to further configure the provided service.
 func ProvideValue[T any](item T) ProviderBuilder[T] {
 	pb := newProviderBuilder[T](provider_value)
@@ -133,29 +243,80 @@ func ProvideValue[T any](item T) ProviderBuilder[T] {
 	return pb
 }
 
-func injectInternal[T any](ctx Context, key string, isNamed bool) (T, error) {
+// injectInternal resolves the provider stored under key, name is the readable
+// form of the key used in errors and resolution paths.
+func injectInternal[T any](ctx Context, key, name string, isNamed bool) (T, error) {
+	item, ok := ctx.getProvider(key, isNamed)
+	if !ok {
+		return utils.Empty[T](), newResolveError(ctx, name, fmt.Errorf("The key %s is not found.", name))
+	}
+	// A group resolves to its latest provider.
+	if g, ok := item.(*providerGroup); ok {
+		item = g.last()
+	}
+	return resolveProvider[T](ctx, item, name)
+}
+
+func injectGroupInternal[T any](ctx Context, key, name string, isNamed bool) ([]T, error) {
 	item, ok := ctx.getProvider(key, isNamed)
 	if !ok {
-		return utils.Empty[T](), fmt.Errorf("The key %s is not found.", key)
+		return nil, newResolveError(ctx, name, fmt.Errorf("The key %s is not found.", name))
 	}
 
+	items := []any{item}
+	if g, ok := item.(*providerGroup); ok {
+		items = g.items
+	}
+	resp := make([]T, 0, len(items))
+	for i := range items {
+		instance, err := resolveProvider[T](ctx, items[i], name)
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, instance)
+	}
+	return resp, nil
+}
+
+func resolveProvider[T any](ctx Context, item any, name string) (_ T, err error) {
 	p, ok := item.(provider[T])
 	if !ok {
-		return utils.Empty[T](), fmt.Errorf("The item %s is not a valid provider.", key)
+		return utils.Empty[T](), newResolveError(ctx, name, fmt.Errorf("The item %s is not a valid provider.", name))
 
 	}
+	if sc := ctx.getContainer().getScope(); sc.observed() {
+		// A lazy singleton holds its instance once Get returns, so it is checked beforehand.
+		cp, cached := item.(cachedProvider)
+		cached = cached && cp.cached()
+		begin := time.Now()
+		defer func() {
+			sc.emit(ProviderResolved{Scope: sc.Name, Name: name, Duration: time.Since(begin), Cached: cached, Err: err})
+		}()
+	}
+
 	// Clone a independet context
-	indCtx := ctx.clone()
+	owner := newComponent(name, item)
+	indCtx := ctx.clone(name, owner)
 	defer indCtx.recycle()
 	instance, err := p.Get(indCtx)
 	if err != nil {
-		return utils.Empty[T](), fmt.Errorf("Get %s failed, err: %v", key, err)
+		// Keep the innermost failure, its path already covers the whole chain.
+		var rerr *ResolveError
+		if errors.As(err, &rerr) {
+			return utils.Empty[T](), rerr
+		}
+		return utils.Empty[T](), newResolveError(ctx, name, fmt.Errorf("Get %s failed, err: %w", name, err))
+	}
+
+	// Each transient instance owns its hooks, so that Release can dispose of it alone.
+	if _, ok := p.(*factoryProvider[T]); ok {
+		owner.instance = instance
 	}
 
 	// try to register hook.
 	err = indCtx.tryAddOrRunHook()
 	if err != nil {
-		return utils.Empty[T](), fmt.Errorf("Execution of the startup hook for the %s failed. err: %v", key, err)
+		return utils.Empty[T](), newResolveError(ctx, name, fmt.Errorf("Execution of the startup hook for the %s failed. err: %w", name, err))
 	}
 
 	return instance, nil
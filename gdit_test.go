@@ -196,6 +196,77 @@ func TestInvokeWithHook(t *testing.T) {
 
 }
 
+func TestResolveError(t *testing.T) {
+	app := gdit.New()
+	gdit.Provide[TestService](func(ctx gdit.InvokeCtx) (TestService, error) {
+		repo, err := gdit.Inject[*testRepo](ctx)
+		return &testService{repo: repo}, err
+	}).Attach(app)
+	gdit.Provide[*testRepo](func(ctx gdit.InvokeCtx) (*testRepo, error) {
+		cfg, err := gdit.Inject[*testConfig](ctx)
+		return &testRepo{cfg: cfg}, err
+	}).Attach(app)
+
+	_, err := gdit.Invoke(app, func(ctx gdit.InvokeCtx) (TestService, error) {
+		return gdit.Inject[TestService](ctx)
+	})
+
+	t.Run("The error should carry the resolution path", func(ct *testing.T) {
+		var rerr *gdit.ResolveError
+		if !errors.As(err, &rerr) {
+			ct.Fatalf("unexpected error: %v", err)
+		}
+		if rerr.PathString() != "gdit_test.TestService -> *gdit_test.testRepo -> *gdit_test.testConfig" {
+			ct.Errorf("unexpected path: %s", rerr.PathString())
+		}
+	})
+}
+
+func TestNamedProvider(t *testing.T) {
+	app := gdit.New()
+	cfg := &testConfig{DomainUrl: "http://primary.example.com"}
+	repo := &testRepo{cfg: cfg}
+	gdit.ProvideValue[*testConfig](cfg).WithName("primary").Attach(app)
+	gdit.ProvideValue[*testRepo](repo).WithName("primary").Attach(app)
+
+	primaryRepo := gdit.NewKey[*testRepo]("primary")
+
+	gdit.InvokeFunc(app, func(ctx gdit.InvokeCtx) error {
+		t.Run("Providers of different types should not overwrite each other", func(ct *testing.T) {
+			if gdit.MustInjectNamed[*testConfig](ctx, "primary") != cfg {
+				ct.Fail()
+			}
+			if gdit.MustInjectNamed[*testRepo](ctx, "primary") != repo {
+				ct.Fail()
+			}
+		})
+
+		t.Run("The typed key should resolve the named provider", func(ct *testing.T) {
+			item, err := gdit.InjectKey(ctx, primaryRepo)
+			if err != nil || item != repo {
+				ct.Fail()
+			}
+		})
+
+		t.Run("A key without a name should resolve the provider registered by type", func(ct *testing.T) {
+			key := gdit.NewKey[*testClock]("")
+			clock := &testClock{}
+			gdit.ProvideValue(clock).WithKey(key).Attach(app)
+			item, err := gdit.InjectKey(ctx, key)
+			if err != nil || item != clock || gdit.MustInjectKey(ctx, key) != clock {
+				ct.Errorf("unexpected item %v with error %v", item, err)
+			}
+		})
+
+		t.Run("The name registered for another type should not be found", func(ct *testing.T) {
+			if _, err := gdit.InjectNamed[TestService](ctx, "primary"); err == nil {
+				ct.Fail()
+			}
+		})
+		return nil
+	})
+}
+
 func getTestApp() gdit.App {
 	app := gdit.New().SetLogLevel(gdit.LOG_DEBUG)
 
@@ -0,0 +1,144 @@
+// Package gditest provides helpers for testing code built on gdit.
+//
+// A test builds its container from the same modules as production, swaps the
+// providers it wants to fake with Replace or ReplaceNamed, and lets New take care
+// of Startup and Teardown:
+//
+//	app := gditest.New(t,
+//		RegisterRepositories,
+//		gditest.Replace[*sql.DB](fakeDB),
+//	)
+//	serv := gditest.Inject[*UserService](t, app)
+package gditest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/saweima12/gdit"
+	"github.com/saweima12/gdit/internal/utils"
+)
+
+// Module registers providers or invokes initialization functions on an app.
+// Production code can expose its wiring as modules so that tests reuse it as is.
+type Module func(app gdit.App) error
+
+// New creates an app, applies the modules in order and starts it.
+// Teardown is registered with t.Cleanup, so the app is stopped when the test ends.
+// The app logs through t.Logf, keeping the container output next to the test that produced it.
+func New(t testing.TB, modules ...Module) gdit.App {
+	t.Helper()
+
+	app := gdit.New().SetLogger(&testLogger{t: t})
+	for i, m := range modules {
+		if err := m(app); err != nil {
+			t.Fatalf("gditest: module #%d failed, err: %v", i, err)
+		}
+	}
+
+	if err := app.Startup(); err != nil {
+		t.Fatalf("gditest: startup failed%s", describeError(err))
+	}
+	t.Cleanup(func() {
+		// The test may have torn the app down itself.
+		if app.CurState() == gdit.STATE_TERMINATED {
+			return
+		}
+		if err := app.Teardown(); err != nil {
+			t.Errorf("gditest: teardown failed, err: %v", err)
+		}
+	})
+	return app
+}
+
+// Replace returns a module that registers item as the provider of type T,
+// overwriting any provider registered by the modules before it.
+// Place it after the modules that register the original provider, and before
+// any module that resolves it.
+func Replace[T any](item T) Module {
+	return func(app gdit.App) error {
+		return gdit.ProvideValue[T](item).Attach(app)
+	}
+}
+
+// ReplaceNamed behaves like Replace for the provider registered with the given name.
+func ReplaceNamed[T any](name string, item T) Module {
+	return func(app gdit.App) error {
+		return gdit.ProvideValue[T](item).WithName(name).Attach(app)
+	}
+}
+
+// Inject resolves a dependency of type T from the container and fails the test
+// with the resolution path if it cannot be resolved.
+func Inject[T any](t testing.TB, c gdit.Container) T {
+	t.Helper()
+	item, err := gdit.Invoke(c, func(ctx gdit.InvokeCtx) (T, error) {
+		return gdit.Inject[T](ctx)
+	})
+	if err != nil {
+		t.Fatalf("gditest: inject %s failed%s", utils.GetType[T](), describeError(err))
+	}
+	return item
+}
+
+// InjectNamed behaves like Inject for a dependency registered with the given name.
+func InjectNamed[T any](t testing.TB, c gdit.Container, name string) T {
+	t.Helper()
+	item, err := gdit.Invoke(c, func(ctx gdit.InvokeCtx) (T, error) {
+		return gdit.InjectNamed[T](ctx, name)
+	})
+	if err != nil {
+		t.Fatalf("gditest: inject %s named %q failed%s", utils.GetType[T](), name, describeError(err))
+	}
+	return item
+}
+
+// describeError renders an error for a test failure, listing the resolution path
+// one dependency per line when the error carries one.
+func describeError(err error) string {
+	var rerr *gdit.ResolveError
+	if !errors.As(err, &rerr) {
+		return fmt.Sprintf(", err: %v", err)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\nresolution path:")
+	for i, key := range rerr.Path {
+		sb.WriteString("\n  ")
+		sb.WriteString(strings.Repeat("  ", i))
+		if i > 0 {
+			sb.WriteString("-> ")
+		}
+		sb.WriteString(key)
+	}
+	sb.WriteString("\nerr: ")
+	sb.WriteString(rerr.Err.Error())
+	return sb.String()
+}
+
+type testLogger struct {
+	t testing.TB
+}
+
+func (l *testLogger) Debug(format string, args ...any) {
+	l.log("GDIT-DEBUG", format, args...)
+}
+
+func (l *testLogger) Info(format string, args ...any) {
+	l.log("GDIT-INFO", format, args...)
+}
+
+func (l *testLogger) Warn(format string, args ...any) {
+	l.log("GDIT-WARN", format, args...)
+}
+
+func (l *testLogger) Error(format string, args ...any) {
+	l.log("GDIT-ERROR", format, args...)
+}
+
+func (l *testLogger) log(level, format string, args ...any) {
+	l.t.Helper()
+	l.t.Logf("[%s] %s", level, fmt.Sprintf(format, args...))
+}
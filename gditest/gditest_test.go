@@ -0,0 +1,108 @@
+package gditest_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/saweima12/gdit"
+	"github.com/saweima12/gdit/gditest"
+)
+
+type testConfig struct {
+	DomainUrl string
+}
+
+type testRepo struct {
+	cfg *testConfig
+}
+
+func NewTestRepo(ctx gdit.InvokeCtx) (*testRepo, error) {
+	cfg, err := gdit.Inject[*testConfig](ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &testRepo{cfg: cfg}, nil
+}
+
+func repoModule(app gdit.App) error {
+	gdit.ProvideValue[*testConfig](&testConfig{DomainUrl: "http://example.com"}).Attach(app)
+	gdit.Provide[*testRepo](NewTestRepo).Attach(app)
+	return nil
+}
+
+// fatalRecorder records Fatalf instead of stopping the test.
+type fatalRecorder struct {
+	testing.TB
+	msg string
+}
+
+func (f *fatalRecorder) Fatalf(format string, args ...any) {
+	f.msg = fmt.Sprintf(format, args...)
+}
+
+func TestNew(t *testing.T) {
+	stopped := false
+
+	t.Run("The app should be ready and stopped on cleanup", func(ct *testing.T) {
+		app := gditest.New(ct, repoModule, func(app gdit.App) error {
+			return gdit.InvokeFunc(app, func(ctx gdit.InvokeCtx) error {
+				ctx.OnStop(func(stopCtx gdit.StopCtx) error {
+					stopped = true
+					return nil
+				})
+				return nil
+			})
+		})
+		if app.CurState() != gdit.STATE_READY {
+			ct.Fail()
+		}
+	})
+
+	if !stopped {
+		t.Fail()
+	}
+}
+
+func TestReplace(t *testing.T) {
+	fake := &testConfig{DomainUrl: "http://fake.example.com"}
+
+	t.Run("The repo should receive the replaced config", func(ct *testing.T) {
+		app := gditest.New(ct, repoModule, gditest.Replace[*testConfig](fake))
+		repo := gditest.Inject[*testRepo](ct, app)
+		if repo.cfg != fake {
+			ct.Fail()
+		}
+	})
+
+	t.Run("The named provider should be replaced", func(ct *testing.T) {
+		app := gditest.New(ct,
+			func(app gdit.App) error {
+				gdit.ProvideValue[*testConfig](&testConfig{}).WithName("cfg").Attach(app)
+				return nil
+			},
+			gditest.ReplaceNamed[*testConfig]("cfg", fake),
+		)
+		if gditest.InjectNamed[*testConfig](ct, app, "cfg") != fake {
+			ct.Fail()
+		}
+	})
+}
+
+func TestInjectFailure(t *testing.T) {
+	app := gditest.New(t, func(app gdit.App) error {
+		gdit.Provide[*testRepo](NewTestRepo).Attach(app)
+		return nil
+	})
+
+	rec := &fatalRecorder{TB: t}
+	gditest.Inject[*testRepo](rec, app)
+
+	t.Run("The failure message should show the resolution path", func(ct *testing.T) {
+		if !strings.Contains(rec.msg, "resolution path:") ||
+			!strings.Contains(rec.msg, "*gditest_test.testRepo") ||
+			!strings.Contains(rec.msg, "-> *gditest_test.testConfig") {
+			ct.Errorf("unexpected message: %s", rec.msg)
+		}
+	})
+}
@@ -1,3 +1,3 @@
 module github.com/saweima12/gdit
 
-go 1.18
+go 1.20
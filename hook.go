@@ -0,0 +1,224 @@
+package gdit
+
+import (
+	"fmt"
+	"time"
+)
+
+// component identifies what registered a set of hooks: the provider that
+// created an instance, or an Invoke call.
+type component struct {
+	name     string
+	provider any
+	// instance is set for the transient instances of a factory provider, see Release.
+	instance any
+}
+
+func newComponent(name string, provider any) *component {
+	return &component{name: name, provider: provider}
+}
+
+// LifecyclePhase orders the lifecycle hooks. Startup completes each start phase across
+// the app and all of its scopes before moving to the next, and so does Teardown for the stop phases.
+type LifecyclePhase uint32
+
+const (
+	// PHASE_PRE_START prepares what must be in place before serving, such as warming caches.
+	PHASE_PRE_START LifecyclePhase = iota
+	// PHASE_START opens the listeners and starts the workers.
+	PHASE_START
+	// PHASE_READY runs once every component has started, such as flipping the readiness probe.
+	PHASE_READY
+	// PHASE_PRE_STOP stops accepting new work and drains the connections in flight.
+	PHASE_PRE_STOP
+	// PHASE_STOP releases the resources, such as closing the pools.
+	PHASE_STOP
+	// PHASE_RELOAD runs the OnReload hooks, outside of Startup and Teardown, see App.Reload.
+	PHASE_RELOAD
+)
+
+var (
+	startPhases = []LifecyclePhase{PHASE_PRE_START, PHASE_START, PHASE_READY}
+	stopPhases  = []LifecyclePhase{PHASE_PRE_STOP, PHASE_STOP}
+)
+
+func (lp LifecyclePhase) String() string {
+	switch lp {
+	case PHASE_PRE_START:
+		return "PreStart"
+	case PHASE_START:
+		return "Start"
+	case PHASE_READY:
+		return "Ready"
+	case PHASE_PRE_STOP:
+		return "PreStop"
+	case PHASE_STOP:
+		return "Stop"
+	case PHASE_RELOAD:
+		return "Reload"
+	default:
+		return fmt.Sprintf("UNKNOWN(%d)", int(lp))
+	}
+}
+
+// HookOption configures a hook registered by OnStart, OnStop or OnReload.
+type HookOption func(opts *hookOptions)
+
+type hookOptions struct {
+	name     string
+	priority int
+	after    []string
+	before   []string
+}
+
+// WithHookName names a hook, so logs and errors can tell apart the hooks of the same component,
+// and After and Before can refer to it.
+func WithHookName(name string) HookOption {
+	return func(opts *hookOptions) {
+		opts.name = name
+	}
+}
+
+// WithPriority orders the hooks of a phase within a scope: the higher the priority, the earlier
+// a start or reload hook runs and the later a stop hook runs. The default priority is 0, hooks of the same
+// priority keep the registration order.
+func WithPriority(priority int) HookOption {
+	return func(opts *hookOptions) {
+		opts.priority = priority
+	}
+}
+
+// After runs the hook after the hooks named by WithHookName, when they belong to the same scope and phase.
+// It takes precedence over the priorities, and Startup fails if the constraints form a cycle,
+// or if a start hook names no start hook of its phase. For a stop or reload hook the unknown name is logged.
+// Like the priorities, it is mirrored for the stop hooks: a stop hook After `db` runs before the stop hook named `db`.
+func After(names ...string) HookOption {
+	return func(opts *hookOptions) {
+		opts.after = append(opts.after, names...)
+	}
+}
+
+// Before runs the hook before the hooks named by WithHookName, when they belong to the same scope and phase.
+// It takes precedence over the priorities, and Startup rejects a cycle or an unknown name like After.
+// It is mirrored for the stop hooks, see After.
+func Before(names ...string) HookOption {
+	return func(opts *hookOptions) {
+		opts.before = append(opts.before, names...)
+	}
+}
+
+func newHookOptions(opts []HookOption) hookOptions {
+	ho := hookOptions{}
+	for _, opt := range opts {
+		opt(&ho)
+	}
+	return ho
+}
+
+// hookLabel returns the readable name of a hook, such as `*Server` or `*Server(listen)`.
+func hookLabel(owner *component, name string) string {
+	if name == "" {
+		return owner.name
+	}
+	return fmt.Sprintf("%s(%s)", owner.name, name)
+}
+
+// hookFields returns the log attributes of a hook that has run.
+func hookFields(scope string, owner *component, name string, phase LifecyclePhase, duration time.Duration) []Field {
+	return []Field{
+		{"scope", scope},
+		{"owner", owner.name},
+		{"hook", name},
+		{"phase", phase},
+		{"duration", duration},
+	}
+}
+
+type startHook struct {
+	hookOptions
+	owner *component
+	phase LifecyclePhase
+	fn    StartFunc
+	// taken is set once Startup has picked the hook to run.
+	taken bool
+}
+
+// run calls the hook with ctx owned by the hook's component. It returns the stop hooks
+// registered by the hook through StartCtx.OnStop, owned by the same component.
+func (h startHook) run(ctx *context) (_ []stopHook, err error) {
+	ctx.owner = h.owner
+	defer func() {
+		ctx.owner = nil
+		ctx.stopHooks = nil
+	}()
+	if sc := ctx.container.getScope(); sc.observed() {
+		done := observeHook(sc, sc.Name, h.owner, h.name, h.phase)
+		defer func() {
+			// A panicking start hook is reported, then left to crash the caller as before.
+			if r := recover(); r != nil {
+				done(fmt.Errorf("%v", r), true)
+				panic(r)
+			}
+			done(err, false)
+		}()
+	}
+	if err := h.fn(ctx); err != nil {
+		return nil, err
+	}
+	stops := ctx.stopHooks
+	for i := range stops {
+		stops[i].owner = h.owner
+	}
+	return stops, nil
+}
+
+type stopHook struct {
+	hookOptions
+	owner *component
+	phase LifecyclePhase
+	fn    StopFunc
+}
+
+// run calls the hook and recovers from a panic, so the remaining stop hooks still run.
+// It returns a HookError naming the scope and the owner of the hook if it fails.
+func (h stopHook) run(scope string, ctx StopCtx) (herr *HookError) {
+	if sc := ctx.getContainer().getScope(); sc.observed() {
+		done := observeHook(sc, scope, h.owner, h.name, h.phase)
+		// Deferred first, so it runs once the panic is recovered.
+		defer func() {
+			if herr != nil {
+				done(herr.Err, herr.Panicked)
+				return
+			}
+			done(nil, false)
+		}()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			herr = &HookError{Scope: scope, Owner: h.owner.name, Hook: h.name, Phase: h.phase, Err: fmt.Errorf("%v", r), Panicked: true}
+		}
+	}()
+	if err := h.fn(ctx); err != nil {
+		return &HookError{Scope: scope, Owner: h.owner.name, Hook: h.name, Phase: h.phase, Err: err}
+	}
+	return nil
+}
+
+// observeHook emits HookStarted and returns the function emitting the outcome of the hook.
+func observeHook(sc *Scope, scope string, owner *component, name string, phase LifecyclePhase) func(err error, panicked bool) {
+	sc.emit(HookStarted{Scope: scope, Owner: owner.name, Hook: name, Phase: phase})
+	begin := time.Now()
+	return func(err error, panicked bool) {
+		if err != nil {
+			sc.emit(HookFailed{Scope: scope, Owner: owner.name, Hook: name, Phase: phase, Duration: time.Since(begin), Err: err, Panicked: panicked})
+			return
+		}
+		sc.emit(HookFinished{Scope: scope, Owner: owner.name, Hook: name, Phase: phase, Duration: time.Since(begin)})
+	}
+}
+
+type reloadHook struct {
+	hookOptions
+	owner *component
+	fn    ReloadFunc
+}
@@ -30,6 +30,14 @@ func (gsm *GSyncMap[T]) Swap(k string, p T) (prev T, loaded bool) {
 	return resp.(T), loaded
 }
 
+func (gsm *GSyncMap[T]) LoadAndDelete(k string) (T, bool) {
+	resp, loaded := gsm.smp.LoadAndDelete(k)
+	if !loaded {
+		return utils.Empty[T](), loaded
+	}
+	return resp.(T), loaded
+}
+
 func (gsm *GSyncMap[T]) Range(f func(key string, value T) bool) {
 	gsm.smp.Range(func(key, value any) bool {
 		return f(key.(string), value.(T))
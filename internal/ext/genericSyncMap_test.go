@@ -42,6 +42,17 @@ func TestGenericSyncMap(t *testing.T) {
 		}
 	})
 
+	t.Run("The deleted value should be 10 and no longer loaded", func(ct *testing.T) {
+		m.Store("deleted", 10)
+		val, ok := m.LoadAndDelete("deleted")
+		if val != 10 || !ok {
+			t.Fail()
+		}
+		if _, ok := m.Load("deleted"); ok {
+			t.Fail()
+		}
+	})
+
 	t.Run("The Range method will iterate over all values.", func(t *testing.T) {
 		items := []int{}
 		m.Range(func(key string, value int) bool {
@@ -2,19 +2,158 @@ package utils
 
 import (
 	"reflect"
+	"strconv"
+	"strings"
 )
 
 func GetProviderKey[T any](name string) (key string, named bool) {
 	if name != "" {
-		return name, true
+		return GetNamedKey[T](name), true
 	}
-	return GetType[T](), false
+	return GetTypeKey[T](), false
 }
 
+// GetNamedKey returns the key of a provider registered with a name, so that
+// providers of different types may share the same name.
+func GetNamedKey[T any](name string) string {
+	return name + "#" + GetTypeKey[T]()
+}
+
+// GetNamedType returns the readable form of a named provider key, such as `primary(*sql.DB)`.
+func GetNamedType[T any](name string) string {
+	return name + "(" + GetType[T]() + ")"
+}
+
+// GetTypeKey returns an unambiguous key for T, qualified by the full package path
+// of every named type it refers to, such as `github.com/a/config.Config`.
+func GetTypeKey[T any]() string {
+	var sb strings.Builder
+	writeTypeKey(&sb, reflect.TypeOf((*T)(nil)).Elem())
+	return sb.String()
+}
+
+// GetType returns the short readable name of T, such as `config.Config`.
+// It is meant for logs and error messages, use GetTypeKey to identify a type.
 func GetType[T any]() string {
 	return reflect.TypeOf((*T)(nil)).Elem().String()
 }
 
+func writeTypeKey(sb *strings.Builder, t reflect.Type) {
+	// Named types: the name of an instantiated generic type already lists its
+	// type arguments with their full package path.
+	if t.Name() != "" {
+		if pkg := t.PkgPath(); pkg != "" {
+			sb.WriteString(pkg)
+			sb.WriteByte('.')
+		}
+		sb.WriteString(t.Name())
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		sb.WriteByte('*')
+		writeTypeKey(sb, t.Elem())
+	case reflect.Slice:
+		sb.WriteString("[]")
+		writeTypeKey(sb, t.Elem())
+	case reflect.Array:
+		sb.WriteByte('[')
+		sb.WriteString(strconv.Itoa(t.Len()))
+		sb.WriteByte(']')
+		writeTypeKey(sb, t.Elem())
+	case reflect.Map:
+		sb.WriteString("map[")
+		writeTypeKey(sb, t.Key())
+		sb.WriteByte(']')
+		writeTypeKey(sb, t.Elem())
+	case reflect.Chan:
+		switch t.ChanDir() {
+		case reflect.RecvDir:
+			sb.WriteString("<-chan ")
+		case reflect.SendDir:
+			sb.WriteString("chan<- ")
+		default:
+			sb.WriteString("chan ")
+		}
+		writeTypeKey(sb, t.Elem())
+	case reflect.Func:
+		sb.WriteString("func")
+		writeSignatureKey(sb, t)
+	case reflect.Struct:
+		sb.WriteString("struct {")
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if i > 0 {
+				sb.WriteByte(';')
+			}
+			sb.WriteByte(' ')
+			if f.PkgPath != "" {
+				sb.WriteString(f.PkgPath)
+				sb.WriteByte('.')
+			}
+			sb.WriteString(f.Name)
+			sb.WriteByte(' ')
+			writeTypeKey(sb, f.Type)
+			if f.Tag != "" {
+				sb.WriteByte(' ')
+				sb.WriteString(strconv.Quote(string(f.Tag)))
+			}
+		}
+		sb.WriteString(" }")
+	case reflect.Interface:
+		sb.WriteString("interface {")
+		for i := 0; i < t.NumMethod(); i++ {
+			m := t.Method(i)
+			if i > 0 {
+				sb.WriteByte(';')
+			}
+			sb.WriteByte(' ')
+			if m.PkgPath != "" {
+				sb.WriteString(m.PkgPath)
+				sb.WriteByte('.')
+			}
+			sb.WriteString(m.Name)
+			writeSignatureKey(sb, m.Type)
+		}
+		sb.WriteString(" }")
+	default:
+		sb.WriteString(t.String())
+	}
+}
+
+func writeSignatureKey(sb *strings.Builder, t reflect.Type) {
+	sb.WriteByte('(')
+	for i := 0; i < t.NumIn(); i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		if t.IsVariadic() && i == t.NumIn()-1 {
+			sb.WriteString("...")
+			writeTypeKey(sb, t.In(i).Elem())
+			continue
+		}
+		writeTypeKey(sb, t.In(i))
+	}
+	sb.WriteByte(')')
+
+	switch t.NumOut() {
+	case 0:
+	case 1:
+		sb.WriteByte(' ')
+		writeTypeKey(sb, t.Out(0))
+	default:
+		sb.WriteString(" (")
+		for i := 0; i < t.NumOut(); i++ {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			writeTypeKey(sb, t.Out(i))
+		}
+		sb.WriteByte(')')
+	}
+}
+
 func Empty[T any]() (t T) {
 	return
 }
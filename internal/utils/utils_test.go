@@ -8,6 +8,8 @@ import (
 
 type TestItem struct{}
 
+type TestBox[T any] struct{}
+
 func TestHelper(t *testing.T) {
 
 	t.Run("The name must be `utils_test.TestItem`", func(ct *testing.T) {
@@ -17,6 +19,27 @@ func TestHelper(t *testing.T) {
 		}
 	})
 
+	t.Run("The key must include the package path", func(ct *testing.T) {
+		key := utils.GetTypeKey[*TestItem]()
+		if key != "*github.com/saweima12/gdit/internal/utils_test.TestItem" {
+			ct.Errorf("unexpected key %s", key)
+		}
+	})
+
+	t.Run("The key of a generic type must include the package path of its arguments", func(ct *testing.T) {
+		key := utils.GetTypeKey[TestBox[map[string][]*TestItem]]()
+		if key != "github.com/saweima12/gdit/internal/utils_test.TestBox[map[string][]*github.com/saweima12/gdit/internal/utils_test.TestItem]" {
+			ct.Errorf("unexpected key %s", key)
+		}
+	})
+
+	t.Run("The key of an unnamed type must include the package path of its parts", func(ct *testing.T) {
+		key := utils.GetTypeKey[func(...TestItem) (error, chan<- TestItem)]()
+		if key != "func(...github.com/saweima12/gdit/internal/utils_test.TestItem) (error, chan<- github.com/saweima12/gdit/internal/utils_test.TestItem)" {
+			ct.Errorf("unexpected key %s", key)
+		}
+	})
+
 	t.Run("Should be 0, emptyString and nil ", func(ct *testing.T) {
 		if utils.Empty[int]() != 0 {
 			t.Fail()
@@ -29,16 +52,22 @@ func TestHelper(t *testing.T) {
 		}
 	})
 
-	t.Run("Should be `utils_test.TestItem` and false", func(ct *testing.T) {
+	t.Run("Should be `github.com/saweima12/gdit/internal/utils_test.TestItem` and false", func(ct *testing.T) {
 		val, named := utils.GetProviderKey[TestItem]("")
-		if val != "utils_test.TestItem" || named {
+		if val != "github.com/saweima12/gdit/internal/utils_test.TestItem" || named {
 			t.Fail()
 		}
 	})
 
-	t.Run("Should be `TestItem` and true ", func(ct *testing.T) {
+	t.Run("Should be `TestItem#github.com/saweima12/gdit/internal/utils_test.TestItem` and true ", func(ct *testing.T) {
 		val, named := utils.GetProviderKey[TestItem]("TestItem")
-		if val != "TestItem" || !named {
+		if val != "TestItem#github.com/saweima12/gdit/internal/utils_test.TestItem" || !named {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should be `primary(*utils_test.TestItem)`", func(ct *testing.T) {
+		if utils.GetNamedType[*TestItem]("primary") != "primary(*utils_test.TestItem)" {
 			t.Fail()
 		}
 	})
@@ -0,0 +1,24 @@
+package gdit
+
+// Key is a typed token identifying a named provider of type T.
+// Declaring keys once and sharing them turns a mistyped name or a mismatched
+// type into a compile error instead of a failed resolution.
+//
+//	var PrimaryDB = gdit.NewKey[*sql.DB]("primary")
+//
+//	gdit.ProvideValue[*sql.DB](db).WithKey(PrimaryDB).Attach(app)
+//	db, err := gdit.InjectKey(ctx, PrimaryDB)
+type Key[T any] struct {
+	name string
+}
+
+// NewKey creates a typed key for the provider of type T registered with the given name.
+// An empty name stands for the provider registered by type alone.
+func NewKey[T any](name string) Key[T] {
+	return Key[T]{name: name}
+}
+
+// Name returns the name carried by the key.
+func (k Key[T]) Name() string {
+	return k.name
+}
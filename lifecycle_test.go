@@ -0,0 +1,563 @@
+package gdit_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saweima12/gdit"
+)
+
+// testRecorder records the order in which the hooks run.
+type testRecorder struct {
+	events []string
+}
+
+func (r *testRecorder) record(event string) {
+	r.events = append(r.events, event)
+}
+
+func (r *testRecorder) String() string {
+	return strings.Join(r.events, ",")
+}
+
+// addTestComponent registers the hooks of a component recording its name,
+// failStart and failStop make the corresponding hook fail.
+func addTestComponent(c gdit.Container, rec *testRecorder, name string, failStart, failStop bool) {
+	gdit.InvokeFunc(c, func(ctx gdit.InvokeCtx) error {
+		ctx.OnStart(func(startCtx gdit.StartCtx) error {
+			if failStart {
+				return fmt.Errorf("%s start failed", name)
+			}
+			rec.record("start:" + name)
+			return nil
+		})
+		ctx.OnStop(func(stopCtx gdit.StopCtx) error {
+			rec.record("stop:" + name)
+			if failStop {
+				return fmt.Errorf("%s stop failed", name)
+			}
+			return nil
+		})
+		return nil
+	})
+}
+
+func TestStartupRollback(t *testing.T) {
+	rec := &testRecorder{}
+	app := gdit.New()
+	addTestComponent(app, rec, "c1", false, false)
+	addTestComponent(app, rec, "c2", false, true)
+	gdit.InvokeFunc(app, func(ctx gdit.InvokeCtx) error {
+		ctx.OnStop(func(stopCtx gdit.StopCtx) error {
+			rec.record("stop:conn")
+			return nil
+		})
+		return nil
+	})
+	addTestComponent(app, rec, "c3", false, false)
+	addTestComponent(app, rec, "c4", true, false)
+	addTestComponent(app, rec, "c5", false, false)
+	err := app.Startup()
+
+	t.Run("Only the started components should be stopped, in reverse order", func(ct *testing.T) {
+		if rec.String() != "start:c1,start:c2,start:c3,stop:c3,stop:conn,stop:c2,stop:c1" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+
+	t.Run("The error should include the start and rollback failures", func(ct *testing.T) {
+		if err == nil || !strings.Contains(err.Error(), "c4 start failed") || !strings.Contains(err.Error(), "c2 stop failed") {
+			ct.Errorf("unexpected error %v", err)
+		}
+	})
+
+	t.Run("The app should be failed", func(ct *testing.T) {
+		if app.CurState() != gdit.STATE_FAILED {
+			ct.Errorf("unexpected state %v", app.CurState())
+		}
+		if app.Teardown() == nil {
+			ct.Fail()
+		}
+	})
+
+	t.Run("A failure in a scope should roll back the root", func(ct *testing.T) {
+		rec := &testRecorder{}
+		app := gdit.New()
+		addTestComponent(app, rec, "root", false, false)
+		addTestComponent(app.GetScope("sub"), rec, "sub", true, false)
+		if app.Startup() == nil {
+			ct.Fatal("the startup should fail")
+		}
+		if rec.String() != "start:root,stop:root" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+}
+
+type testStore struct{}
+
+func TestTeardownErrors(t *testing.T) {
+	rec := &testRecorder{}
+	app := gdit.New()
+	sub := app.GetScope("sub")
+	addTestComponent(app, rec, "c1", false, false)
+	gdit.Provide[*testStore](func(ctx gdit.InvokeCtx) (*testStore, error) {
+		ctx.OnStop(func(stopCtx gdit.StopCtx) error {
+			panic("store closed twice")
+		})
+		return &testStore{}, nil
+	}).Attach(sub)
+	gdit.InvokeFunc(sub, func(ctx gdit.InvokeCtx) error {
+		_, err := gdit.Inject[*testStore](ctx)
+		return err
+	})
+	addTestComponent(app, rec, "c2", false, true)
+	app.Startup()
+	err := app.Teardown()
+
+	t.Run("Every stop hook should run", func(ct *testing.T) {
+		if rec.String() != "start:c1,start:c2,stop:c2,stop:c1" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+		if app.CurState() != gdit.STATE_TERMINATED {
+			ct.Errorf("unexpected state %v", app.CurState())
+		}
+	})
+
+	t.Run("Each failing hook should be reported with its scope and owner", func(ct *testing.T) {
+		var lerr *gdit.LifecycleError
+		if !errors.As(err, &lerr) || len(lerr.Errors) != 2 {
+			ct.Fatalf("unexpected error %v", err)
+		}
+		if herr := lerr.Errors[0]; herr.Scope != "sub" || herr.Owner != "*gdit_test.testStore" || !herr.Panicked {
+			ct.Errorf("unexpected hook error %+v", herr)
+		}
+		if herr := lerr.Errors[1]; herr.Scope != "root" || herr.Owner != "invoke" || herr.Panicked {
+			ct.Errorf("unexpected hook error %+v", herr)
+		}
+	})
+}
+
+func TestMultipleHooks(t *testing.T) {
+	rec := &testRecorder{}
+	app := gdit.New()
+	gdit.InvokeFunc(app, func(ctx gdit.InvokeCtx) error {
+		ctx.OnStart(func(startCtx gdit.StartCtx) error {
+			rec.record("start:cache")
+			return nil
+		}, gdit.WithHookName("cache"))
+		ctx.OnStart(func(startCtx gdit.StartCtx) error {
+			rec.record("start:listener")
+			return nil
+		}, gdit.WithHookName("listener"))
+		ctx.OnStop(func(stopCtx gdit.StopCtx) error {
+			rec.record("stop:cache")
+			return nil
+		}, gdit.WithHookName("cache"))
+		ctx.OnStop(func(stopCtx gdit.StopCtx) error {
+			rec.record("stop:listener")
+			return errors.New("close failed")
+		}, gdit.WithHookName("listener"))
+		return nil
+	})
+	app.Startup()
+	err := app.Teardown()
+
+	t.Run("Every hook should be kept in order", func(ct *testing.T) {
+		if rec.String() != "start:cache,start:listener,stop:listener,stop:cache" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+
+	t.Run("The failing hook should be named", func(ct *testing.T) {
+		var herr *gdit.HookError
+		if !errors.As(err, &herr) || herr.Hook != "listener" || !strings.Contains(err.Error(), "invoke(listener)") {
+			ct.Errorf("unexpected error %v", err)
+		}
+	})
+}
+
+// addListenerComponent registers a start hook that registers its matching stop hook.
+func addListenerComponent(c gdit.Container, rec *testRecorder, name string) {
+	gdit.InvokeFunc(c, func(ctx gdit.InvokeCtx) error {
+		ctx.OnStart(func(startCtx gdit.StartCtx) error {
+			rec.record("listen:" + name)
+			startCtx.OnStop(func(stopCtx gdit.StopCtx) error {
+				rec.record("close:" + name)
+				return nil
+			})
+			return nil
+		})
+		return nil
+	})
+}
+
+func TestStopHookFromStartHook(t *testing.T) {
+	rec := &testRecorder{}
+	app := gdit.New()
+	addListenerComponent(app, rec, "root")
+	addListenerComponent(app.GetScope("sub"), rec, "sub")
+	app.Startup()
+	addListenerComponent(app, rec, "late")
+
+	t.Run("The stop hooks should run at teardown", func(ct *testing.T) {
+		if err := app.Teardown(); err != nil {
+			ct.Fatal(err)
+		}
+		if rec.String() != "listen:root,listen:sub,listen:late,close:sub,close:late,close:root" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+
+	t.Run("The stop hooks of started components should run on rollback", func(ct *testing.T) {
+		rec := &testRecorder{}
+		app := gdit.New()
+		addListenerComponent(app, rec, "c1")
+		addTestComponent(app, rec, "c2", true, false)
+		app.Startup()
+		if rec.String() != "listen:c1,close:c1" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+}
+
+type testCache struct {
+	warm bool
+}
+
+func TestDynamicHooks(t *testing.T) {
+	rec := &testRecorder{}
+	app := gdit.New()
+	gdit.Provide[*testCache](func(ctx gdit.InvokeCtx) (*testCache, error) {
+		cache := &testCache{}
+		ctx.OnStart(func(startCtx gdit.StartCtx) error {
+			cache.warm = true
+			rec.record("start:cache")
+			return nil
+		})
+		ctx.OnStop(func(stopCtx gdit.StopCtx) error {
+			rec.record("stop:cache")
+			return nil
+		})
+		return cache, nil
+	}).Attach(app)
+	gdit.InvokeFunc(app, func(ctx gdit.InvokeCtx) error {
+		ctx.OnStart(func(startCtx gdit.StartCtx) error {
+			rec.record("start:server")
+			_, err := gdit.Inject[*testCache](startCtx)
+			return err
+		})
+		return nil
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- app.Startup()
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the startup is deadlocked")
+	}
+
+	t.Run("The start hook added during startup should run in the same pass", func(ct *testing.T) {
+		if rec.String() != "start:server,start:cache" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+
+	t.Run("The stop hook added during startup should run at teardown", func(ct *testing.T) {
+		app.Teardown()
+		if rec.String() != "start:server,start:cache,stop:cache" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+}
+
+// addPhasedComponent registers a hook for every phase, recording the phase and the component.
+func addPhasedComponent(c gdit.Container, rec *testRecorder, name string) {
+	gdit.InvokeFunc(c, func(ctx gdit.InvokeCtx) error {
+		ctx.OnStop(func(stopCtx gdit.StopCtx) error {
+			rec.record("stop:" + name)
+			return nil
+		})
+		ctx.OnReady(func(startCtx gdit.StartCtx) error {
+			rec.record("ready:" + name)
+			return nil
+		})
+		ctx.OnStart(func(startCtx gdit.StartCtx) error {
+			rec.record("start:" + name)
+			return nil
+		})
+		ctx.OnPreStart(func(startCtx gdit.StartCtx) error {
+			rec.record("prestart:" + name)
+			return nil
+		})
+		ctx.OnPreStop(func(stopCtx gdit.StopCtx) error {
+			rec.record("prestop:" + name)
+			return nil
+		})
+		return nil
+	})
+}
+
+func TestLifecyclePhases(t *testing.T) {
+	rec := &testRecorder{}
+	app := gdit.New()
+	addPhasedComponent(app, rec, "root")
+	addPhasedComponent(app.GetScope("sub"), rec, "sub")
+
+	t.Run("Each start phase should complete across scopes before the next", func(ct *testing.T) {
+		app.Startup()
+		if rec.String() != "prestart:root,prestart:sub,start:root,start:sub,ready:root,ready:sub" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+
+	t.Run("Each stop phase should complete across scopes before the next", func(ct *testing.T) {
+		rec.events = nil
+		app.Teardown()
+		if rec.String() != "prestop:sub,prestop:root,stop:sub,stop:root" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+
+	t.Run("A scope should stop before the root singletons it uses", func(ct *testing.T) {
+		rec := &testRecorder{}
+		app := gdit.New()
+		gdit.Provide(func(ctx gdit.InvokeCtx) (*testWorker, error) {
+			return &testWorker{rec: rec, name: "pool"}, nil
+		}).Attach(app)
+		gdit.InvokeFunc(app, func(ctx gdit.InvokeCtx) error {
+			_, err := gdit.Inject[*testWorker](ctx)
+			return err
+		})
+		gdit.InvokeFunc(app.GetScope("server"), func(ctx gdit.InvokeCtx) error {
+			if _, err := gdit.Inject[*testWorker](ctx); err != nil {
+				return err
+			}
+			ctx.OnStop(func(stopCtx gdit.StopCtx) error {
+				rec.record("stop:server")
+				return nil
+			})
+			return nil
+		})
+		app.Startup()
+		app.Teardown()
+		if rec.String() != "start:pool,stop:server,stop:pool" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+
+	t.Run("A component added after startup should run its phases in order", func(ct *testing.T) {
+		rec := &testRecorder{}
+		app := gdit.New()
+		app.Startup()
+		addPhasedComponent(app, rec, "late")
+		if rec.String() != "prestart:late,start:late,ready:late" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+}
+
+// addOrderedComponent registers a start and a stop hook named after the component with the given options.
+func addOrderedComponent(c gdit.Container, rec *testRecorder, name string, opts ...gdit.HookOption) {
+	opts = append(opts, gdit.WithHookName(name))
+	gdit.InvokeFunc(c, func(ctx gdit.InvokeCtx) error {
+		ctx.OnStart(func(startCtx gdit.StartCtx) error {
+			rec.record("start:" + name)
+			return nil
+		}, opts...)
+		ctx.OnStop(func(stopCtx gdit.StopCtx) error {
+			rec.record("stop:" + name)
+			return nil
+		}, opts...)
+		return nil
+	})
+}
+
+func TestHookOrdering(t *testing.T) {
+	t.Run("The hooks should follow the priorities and constraints", func(ct *testing.T) {
+		rec := &testRecorder{}
+		app := gdit.New()
+		addOrderedComponent(app, rec, "server", gdit.After("db-migrate"))
+		addOrderedComponent(app, rec, "db-migrate")
+		addOrderedComponent(app, rec, "metrics", gdit.WithPriority(100))
+		addOrderedComponent(app, rec, "cache", gdit.Before("db-migrate"))
+		if err := app.Startup(); err != nil {
+			ct.Fatal(err)
+		}
+		app.Teardown()
+		if rec.String() != "start:metrics,start:cache,start:db-migrate,start:server,"+
+			"stop:server,stop:db-migrate,stop:cache,stop:metrics" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+
+	t.Run("A cycle should fail the startup", func(ct *testing.T) {
+		rec := &testRecorder{}
+		app := gdit.New()
+		addOrderedComponent(app, rec, "a", gdit.After("c"))
+		addOrderedComponent(app, rec, "b", gdit.After("a"))
+		addOrderedComponent(app, rec, "c", gdit.After("b"))
+		err := app.Startup()
+
+		var cerr *gdit.HookCycleError
+		if !errors.As(err, &cerr) || len(cerr.Hooks) != 4 || cerr.Hooks[0] != cerr.Hooks[3] {
+			ct.Fatalf("unexpected error %v", err)
+		}
+		if rec.String() != "" || app.CurState() != gdit.STATE_FAILED {
+			ct.Errorf("nothing should run, got %s in state %v", rec, app.CurState())
+		}
+	})
+
+	t.Run("A constraint naming no hook should fail the startup", func(ct *testing.T) {
+		rec := &testRecorder{}
+		app := gdit.New()
+		addOrderedComponent(app, rec, "server", gdit.After("db-migrate"))
+		addOrderedComponent(app, rec, "db-migrat")
+		err := app.Startup()
+		if err == nil || !strings.Contains(err.Error(), `"db-migrate"`) {
+			ct.Fatalf("unexpected error %v", err)
+		}
+		if rec.String() != "" || app.CurState() != gdit.STATE_FAILED {
+			ct.Errorf("nothing should run, got %s in state %v", rec, app.CurState())
+		}
+	})
+}
+
+type testWorker struct {
+	rec  *testRecorder
+	name string
+}
+
+func (w *testWorker) Start(ctx context.Context) error {
+	w.rec.record("start:" + w.name)
+	return nil
+}
+
+func (w *testWorker) Stop(ctx context.Context) error {
+	w.rec.record("stop:" + w.name)
+	return nil
+}
+
+type testPool struct {
+	rec *testRecorder
+}
+
+func (p *testPool) Close() error {
+	p.rec.record("close:pool")
+	return nil
+}
+
+func TestAutoHooks(t *testing.T) {
+	t.Run("The hooks should be registered from the implemented interfaces", func(ct *testing.T) {
+		rec := &testRecorder{}
+		app := gdit.New()
+		gdit.ProvideValue[*testPool](&testPool{rec: rec}).Attach(app)
+		gdit.Provide[*testWorker](func(ctx gdit.InvokeCtx) (*testWorker, error) {
+			return &testWorker{rec: rec, name: "lazy"}, nil
+		}).Attach(app)
+		gdit.InvokeFunc(app, func(ctx gdit.InvokeCtx) error {
+			_, err := gdit.Inject[*testWorker](ctx)
+			return err
+		})
+		gdit.InvokeProvide[gdit.Starter](app, func(ctx gdit.InvokeCtx) (gdit.Starter, error) {
+			return &testWorker{rec: rec, name: "invoke"}, nil
+		})
+
+		app.Startup()
+		app.Teardown()
+		if rec.String() != "start:lazy,start:invoke,stop:invoke,stop:lazy,close:pool" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+
+	t.Run("The hooks registered by hand should not be doubled", func(ct *testing.T) {
+		rec := &testRecorder{}
+		app := gdit.New()
+		gdit.InvokeProvide[*testWorker](app, func(ctx gdit.InvokeCtx) (*testWorker, error) {
+			w := &testWorker{rec: rec, name: "manual"}
+			ctx.OnStart(func(startCtx gdit.StartCtx) error {
+				return w.Start(context.Background())
+			})
+			return w, nil
+		})
+		app.Startup()
+		app.Teardown()
+		if rec.String() != "start:manual,stop:manual" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+
+	t.Run("A hook of another phase should not replace the auto hook", func(ct *testing.T) {
+		rec := &testRecorder{}
+		app := gdit.New()
+		gdit.InvokeProvide[*testWorker](app, func(ctx gdit.InvokeCtx) (*testWorker, error) {
+			ctx.OnReady(func(startCtx gdit.StartCtx) error {
+				rec.record("ready:metrics")
+				return nil
+			})
+			return &testWorker{rec: rec, name: "worker"}, nil
+		})
+		app.Startup()
+		app.Teardown()
+		if rec.String() != "start:worker,ready:metrics,stop:worker" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+
+	t.Run("A rejected registration should not register auto hooks", func(ct *testing.T) {
+		rec := &testRecorder{}
+		app := gdit.New(gdit.WithDuplicatePolicy(gdit.DUPLICATE_KEEP_FIRST))
+		gdit.ProvideValue[*testWorker](&testWorker{rec: rec, name: "a"}).Attach(app)
+		gdit.ProvideValue[*testWorker](&testWorker{rec: rec, name: "b"}).Attach(app)
+
+		strict := gdit.New(gdit.WithDuplicatePolicy(gdit.DUPLICATE_ERROR))
+		gdit.ProvideValue[*testWorker](&testWorker{rec: rec, name: "c"}).Attach(strict)
+		_, err := gdit.InvokeProvide[*testWorker](strict, func(ctx gdit.InvokeCtx) (*testWorker, error) {
+			return &testWorker{rec: rec, name: "d"}, nil
+		})
+		if err == nil {
+			ct.Fatal("the duplicate should be rejected")
+		}
+
+		app.Startup()
+		app.Teardown()
+		strict.Startup()
+		strict.Teardown()
+		if rec.String() != "start:a,stop:a,start:c,stop:c" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+
+	t.Run("An overwritten value should not be started", func(ct *testing.T) {
+		rec := &testRecorder{}
+		app := gdit.New()
+		gdit.ProvideValue[*testWorker](&testWorker{rec: rec, name: "real"}).Attach(app)
+		gdit.ProvideValue[*testWorker](&testWorker{rec: rec, name: "fake"}).Attach(app)
+		app.Startup()
+		app.Teardown()
+		if rec.String() != "start:fake,stop:fake" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+
+	t.Run("The builder should opt out", func(ct *testing.T) {
+		rec := &testRecorder{}
+		app := gdit.New()
+		gdit.ProvideValue[*testPool](&testPool{rec: rec}).WithoutAutoHooks().Attach(app)
+		app.Startup()
+		app.Teardown()
+		if rec.String() != "" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+}
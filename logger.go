@@ -13,6 +13,26 @@ type Logger interface {
 	Warn(format string, args ...any)
 }
 
+// Field is an attribute of a container log entry, such as the scope or the hook it concerns.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// StructuredLogger is implemented by the loggers that keep the attributes of the container logs,
+// instead of receiving them formatted into the message. See NewSlogLogger.
+type StructuredLogger interface {
+	Logger
+	Log(level LogLevel, msg string, fields ...Field)
+}
+
+// levelLogger is implemented by the loggers whose backend decides the enabled levels,
+// so that the level of the app and the one of the backend stay in sync.
+type levelLogger interface {
+	enabled(level LogLevel) bool
+	setLevel(level LogLevel)
+}
+
 type standardLogger struct {
 	l *log.Logger
 }
@@ -1,5 +1,11 @@
 package gdit
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 type LogLevel int
 
 const (
@@ -15,9 +21,73 @@ type loggerWrapper struct {
 }
 
 func (lo *loggerWrapper) ShouldLog(logLevel LogLevel) bool {
+	if ll, ok := lo.Logger.(levelLogger); ok {
+		return ll.enabled(logLevel)
+	}
 	return logLevel >= lo.Level
 }
 
+// setLogger replaces the logger, adopting the level of a logger that decides its own.
+func (lo *loggerWrapper) setLogger(l Logger) {
+	lo.Logger = l
+	if ll, ok := l.(levelLogger); ok {
+		lo.Level = LOG_ERROR
+		for _, level := range []LogLevel{LOG_DEBUG, LOG_INFO, LOG_WARN} {
+			if ll.enabled(level) {
+				lo.Level = level
+				break
+			}
+		}
+	}
+}
+
+// setLevel changes the level, and the one of a logger that decides its own.
+func (lo *loggerWrapper) setLevel(level LogLevel) {
+	lo.Level = level
+	if ll, ok := lo.Logger.(levelLogger); ok {
+		ll.setLevel(level)
+	}
+}
+
+// log writes a message with its attributes. A StructuredLogger receives them as they are,
+// other loggers receive them appended to the message as `key=value`.
+func (lo *loggerWrapper) log(level LogLevel, msg string, fields ...Field) {
+	if !lo.ShouldLog(level) {
+		return
+	}
+	if sl, ok := lo.Logger.(StructuredLogger); ok {
+		sl.Log(level, msg, fields...)
+		return
+	}
+	line := formatFields(msg, fields)
+	switch level {
+	case LOG_DEBUG:
+		lo.Logger.Debug("%s", line)
+	case LOG_INFO:
+		lo.Logger.Info("%s", line)
+	case LOG_WARN:
+		lo.Logger.Warn("%s", line)
+	default:
+		lo.Logger.Error("%s", line)
+	}
+}
+
+func formatFields(msg string, fields []Field) string {
+	if len(fields) == 0 {
+		return msg
+	}
+	var sb strings.Builder
+	sb.WriteString(msg)
+	for _, f := range fields {
+		val := fmt.Sprint(f.Value)
+		if strings.ContainsAny(val, " \t\n\"=") {
+			val = strconv.Quote(val)
+		}
+		sb.WriteString(" " + f.Key + "=" + val)
+	}
+	return sb.String()
+}
+
 func (lo *loggerWrapper) Debug(format string, args ...any) {
 	if lo.ShouldLog(LOG_DEBUG) {
 		lo.Logger.Debug(format, args...)
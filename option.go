@@ -0,0 +1,65 @@
+package gdit
+
+import (
+	"fmt"
+	"time"
+)
+
+// Option configures the app created by New.
+type Option func(ap *app)
+
+// DuplicatePolicy decides what a container does when a provider is registered
+// under a key that already holds one.
+type DuplicatePolicy uint8
+
+const (
+	// DUPLICATE_KEEP_LAST replaces the previous provider and logs a warning. The hooks of the
+	// previous provider are dropped, so a value overwritten before Startup is never started.
+	DUPLICATE_KEEP_LAST DuplicatePolicy = iota
+	// DUPLICATE_KEEP_FIRST keeps the previous provider and ignores the new one with a warning.
+	DUPLICATE_KEEP_FIRST
+	// DUPLICATE_ERROR rejects the new provider with a *DuplicateError.
+	DUPLICATE_ERROR
+	// DUPLICATE_GROUP keeps every provider in a group. Inject resolves the last one,
+	// InjectGroup resolves all of them in registration order.
+	DUPLICATE_GROUP
+)
+
+func (dp DuplicatePolicy) String() string {
+	switch dp {
+	case DUPLICATE_KEEP_LAST:
+		return "KEEP_LAST"
+	case DUPLICATE_KEEP_FIRST:
+		return "KEEP_FIRST"
+	case DUPLICATE_ERROR:
+		return "ERROR"
+	case DUPLICATE_GROUP:
+		return "GROUP"
+	default:
+		return fmt.Sprintf("UNKNOWN(%d)", int(dp))
+	}
+}
+
+// WithDuplicatePolicy sets the policy applied when a provider is registered twice
+// under the same key. Scopes created by the app share the policy.
+// The default is DUPLICATE_KEEP_LAST.
+func WithDuplicatePolicy(policy DuplicatePolicy) Option {
+	return func(ap *app) {
+		ap.dupPolicy = policy
+	}
+}
+
+// WithAutoSeal seals the app once Startup reaches STATE_READY, see App.Seal.
+func WithAutoSeal() Option {
+	return func(ap *app) {
+		ap.autoSeal = true
+	}
+}
+
+// WithShutdownTimeout bounds the teardown run by App.Run, 0 waits for it indefinitely.
+// The default is 30 seconds.
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(ap *app) {
+		ap.shutdownTimeout = d
+	}
+}
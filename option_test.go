@@ -0,0 +1,75 @@
+package gdit_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/saweima12/gdit"
+)
+
+func TestDuplicatePolicy(t *testing.T) {
+	first := &testConfig{DomainUrl: "http://first.example.com"}
+	second := &testConfig{DomainUrl: "http://second.example.com"}
+
+	register := func(app gdit.App) error {
+		gdit.ProvideValue[*testConfig](first).Attach(app)
+		return gdit.ProvideValue[*testConfig](second).Attach(app)
+	}
+
+	t.Run("KEEP_LAST should resolve the second provider", func(ct *testing.T) {
+		app := gdit.New()
+		if err := register(app); err != nil {
+			ct.Fatal(err)
+		}
+		gdit.InvokeFunc(app, func(ctx gdit.InvokeCtx) error {
+			if gdit.MustInject[*testConfig](ctx) != second {
+				ct.Fail()
+			}
+			return nil
+		})
+	})
+
+	t.Run("KEEP_FIRST should resolve the first provider", func(ct *testing.T) {
+		app := gdit.New(gdit.WithDuplicatePolicy(gdit.DUPLICATE_KEEP_FIRST))
+		if err := register(app); err != nil {
+			ct.Fatal(err)
+		}
+		gdit.InvokeFunc(app, func(ctx gdit.InvokeCtx) error {
+			if gdit.MustInject[*testConfig](ctx) != first {
+				ct.Fail()
+			}
+			return nil
+		})
+	})
+
+	t.Run("ERROR should report where both registrations came from", func(ct *testing.T) {
+		app := gdit.New(gdit.WithDuplicatePolicy(gdit.DUPLICATE_ERROR))
+		var derr *gdit.DuplicateError
+		if !errors.As(register(app), &derr) {
+			ct.Fatal("a DuplicateError is expected")
+		}
+		if !strings.Contains(derr.First, "option_test.go") ||
+			!strings.Contains(derr.Second, "option_test.go") ||
+			derr.First == derr.Second {
+			ct.Errorf("unexpected sources: %s", derr.Error())
+		}
+	})
+
+	t.Run("GROUP should keep both providers", func(ct *testing.T) {
+		app := gdit.New(gdit.WithDuplicatePolicy(gdit.DUPLICATE_GROUP))
+		if err := register(app); err != nil {
+			ct.Fatal(err)
+		}
+		gdit.InvokeFunc(app, func(ctx gdit.InvokeCtx) error {
+			group, err := gdit.InjectGroup[*testConfig](ctx)
+			if err != nil || len(group) != 2 || group[0] != first || group[1] != second {
+				ct.Fail()
+			}
+			if gdit.MustInject[*testConfig](ctx) != second {
+				ct.Fail()
+			}
+			return nil
+		})
+	})
+}
@@ -0,0 +1,233 @@
+package gdit
+
+import "fmt"
+
+// scheduleHooks returns the order in which n hooks run. A hook constrained by After or Before
+// runs after or before the hooks it names, or the other way round when mirror is set, then first
+// picks the next hook among those free to run. If the constraints cannot be satisfied, it returns
+// the hooks forming a cycle instead, the first one repeated at the end.
+func scheduleHooks(n int, mirror bool, opts func(i int) *hookOptions, first func(i, j int) bool) (order []int, cycle []int) {
+	byName := make(map[string][]int)
+	for i := 0; i < n; i++ {
+		if name := opts(i).name; name != "" {
+			byName[name] = append(byName[name], i)
+		}
+	}
+
+	succs := make([][]int, n)
+	preds := make([][]int, n)
+	indeg := make([]int, n)
+	addEdge := func(from, to int) {
+		if from == to {
+			return
+		}
+		if mirror {
+			from, to = to, from
+		}
+		succs[from] = append(succs[from], to)
+		preds[to] = append(preds[to], from)
+		indeg[to]++
+	}
+	for i := 0; i < n; i++ {
+		for _, name := range opts(i).after {
+			for _, j := range byName[name] {
+				addEdge(j, i)
+			}
+		}
+		for _, name := range opts(i).before {
+			for _, j := range byName[name] {
+				addEdge(i, j)
+			}
+		}
+	}
+
+	done := make([]bool, n)
+	order = make([]int, 0, n)
+	for len(order) < n {
+		next := -1
+		for i := 0; i < n; i++ {
+			if !done[i] && indeg[i] == 0 && (next < 0 || first(i, next)) {
+				next = i
+			}
+		}
+		if next < 0 {
+			return nil, findCycle(preds, done)
+		}
+		done[next] = true
+		order = append(order, next)
+		for _, j := range succs[next] {
+			indeg[j]--
+		}
+	}
+	return order, nil
+}
+
+// findCycle walks the predecessors of the hooks left unscheduled, each of them has one,
+// until a hook repeats.
+func findCycle(preds [][]int, done []bool) []int {
+	v := 0
+	for done[v] {
+		v++
+	}
+	seen := make(map[int]int)
+	var walk []int
+	for {
+		if pos, ok := seen[v]; ok {
+			// The walk follows the edges backwards.
+			cycle := make([]int, 0, len(walk)-pos+1)
+			for i := len(walk) - 1; i >= pos; i-- {
+				cycle = append(cycle, walk[i])
+			}
+			return append(cycle, cycle[0])
+		}
+		seen[v] = len(walk)
+		walk = append(walk, v)
+		for _, u := range preds[v] {
+			if !done[u] {
+				v = u
+				break
+			}
+		}
+	}
+}
+
+// orderStartHooks orders start hooks of the same phase: by priority, highest first, then in registration order.
+func orderStartHooks(scope string, phase LifecyclePhase, hooks []startHook) ([]startHook, error) {
+	order, cycle := scheduleHooks(len(hooks), false, func(i int) *hookOptions {
+		return &hooks[i].hookOptions
+	}, func(i, j int) bool {
+		if hooks[i].priority != hooks[j].priority {
+			return hooks[i].priority > hooks[j].priority
+		}
+		return i < j
+	})
+	if cycle != nil {
+		return nil, newHookCycleError(scope, phase, cycle, func(i int) string {
+			return hookLabel(hooks[i].owner, hooks[i].name)
+		})
+	}
+	ordered := make([]startHook, len(order))
+	for i, idx := range order {
+		ordered[i] = hooks[idx]
+	}
+	return ordered, nil
+}
+
+// orderReloadHooks orders the reload hooks of a scope like the start hooks, see orderStartHooks.
+func orderReloadHooks(scope string, hooks []reloadHook) ([]reloadHook, error) {
+	order, cycle := scheduleHooks(len(hooks), false, func(i int) *hookOptions {
+		return &hooks[i].hookOptions
+	}, func(i, j int) bool {
+		if hooks[i].priority != hooks[j].priority {
+			return hooks[i].priority > hooks[j].priority
+		}
+		return i < j
+	})
+	if cycle != nil {
+		return nil, newHookCycleError(scope, PHASE_RELOAD, cycle, func(i int) string {
+			return hookLabel(hooks[i].owner, hooks[i].name)
+		})
+	}
+	ordered := make([]reloadHook, len(order))
+	for i, idx := range order {
+		ordered[i] = hooks[idx]
+	}
+	return ordered, nil
+}
+
+// orderStopHooks orders stop hooks of the same phase in the mirror of the start order: the constraints
+// are reversed, then the hooks run by priority, lowest first, then in reverse registration order.
+// If the constraints form a cycle, the hooks are returned in the default order along with the error.
+func orderStopHooks(scope string, phase LifecyclePhase, hooks []stopHook) ([]stopHook, error) {
+	order, cycle := scheduleHooks(len(hooks), true, func(i int) *hookOptions {
+		return &hooks[i].hookOptions
+	}, func(i, j int) bool {
+		if hooks[i].priority != hooks[j].priority {
+			return hooks[i].priority < hooks[j].priority
+		}
+		return i > j
+	})
+	ordered := make([]stopHook, len(hooks))
+	if cycle != nil {
+		for i := range hooks {
+			ordered[i] = hooks[len(hooks)-1-i]
+		}
+		return ordered, newHookCycleError(scope, phase, cycle, func(i int) string {
+			return hookLabel(hooks[i].owner, hooks[i].name)
+		})
+	}
+	for i, idx := range order {
+		ordered[i] = hooks[idx]
+	}
+	return ordered, nil
+}
+
+// checkHookOrder reports the first cycle among the constraints of the hooks of the scope,
+// or the first start hook constrained by a name that no start hook of its phase carries.
+// Stop and reload hooks may still be registered once started, an unknown name is only logged for them.
+func (sc *Scope) checkHookOrder() error {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+
+	for _, phase := range startPhases {
+		var hooks []startHook
+		for _, h := range sc.startHooks {
+			if h.phase == phase {
+				hooks = append(hooks, h)
+			}
+		}
+		if i, name, ok := unknownHookName(len(hooks), func(i int) *hookOptions { return &hooks[i].hookOptions }); ok {
+			return fmt.Errorf("[%s] -> The %v hook of %s is ordered against %q, no %v hook of the scope has this name.",
+				sc.Name, phase, hookLabel(hooks[i].owner, hooks[i].name), name, phase)
+		}
+		if _, err := orderStartHooks(sc.Name, phase, hooks); err != nil {
+			return err
+		}
+	}
+	for _, phase := range stopPhases {
+		var hooks []stopHook
+		for _, h := range sc.stopHooks {
+			if h.phase == phase {
+				hooks = append(hooks, h)
+			}
+		}
+		if i, name, ok := unknownHookName(len(hooks), func(i int) *hookOptions { return &hooks[i].hookOptions }); ok {
+			sc.warnUnknownHookName(hooks[i].owner, hooks[i].name, phase, name)
+		}
+		if _, err := orderStopHooks(sc.Name, phase, hooks); err != nil {
+			return err
+		}
+	}
+	hooks := sc.reloadHooks
+	if i, name, ok := unknownHookName(len(hooks), func(i int) *hookOptions { return &hooks[i].hookOptions }); ok {
+		sc.warnUnknownHookName(hooks[i].owner, hooks[i].name, PHASE_RELOAD, name)
+	}
+	_, err := orderReloadHooks(sc.Name, hooks)
+	return err
+}
+
+// unknownHookName returns the first hook constrained by After or Before with a name that none of the n hooks carries.
+func unknownHookName(n int, opts func(i int) *hookOptions) (hook int, name string, ok bool) {
+	names := make(map[string]struct{}, n)
+	for i := 0; i < n; i++ {
+		if name := opts(i).name; name != "" {
+			names[name] = struct{}{}
+		}
+	}
+	for i := 0; i < n; i++ {
+		o := opts(i)
+		for _, list := range [][]string{o.after, o.before} {
+			for _, name := range list {
+				if _, found := names[name]; !found {
+					return i, name, true
+				}
+			}
+		}
+	}
+	return 0, "", false
+}
+
+func (sc *Scope) warnUnknownHookName(owner *component, hook string, phase LifecyclePhase, name string) {
+	sc.Logger.log(LOG_WARN, "The hook is ordered against a name that no hook of its phase has.",
+		Field{"scope", sc.Name}, Field{"owner", owner.name}, Field{"hook", hook}, Field{"phase", phase}, Field{"name", name})
+}
@@ -1,16 +1,39 @@
 package gdit
 
-import "sync"
+import (
+	"sync"
+	"sync/atomic"
+)
 
 type provider[T any] interface {
 	Get(ctx InvokeCtx) (T, error)
 	IsNamed() bool
 	Key() string
+	Name() string
+}
+
+// keyedProvider is implemented by every provider, whatever its type argument.
+type keyedProvider interface {
+	Key() string
+	IsNamed() bool
+}
+
+// forkable is implemented by providers that hold state which must not be
+// shared with a forked app.
+type forkable interface {
+	fork() any
+}
+
+// cachedProvider is implemented by providers that may return an instance they already hold.
+type cachedProvider interface {
+	cached() bool
 }
 
 type baseProvider struct {
-	key   string
-	named bool
+	key    string
+	name   string
+	named  bool
+	source string
 }
 
 func (p *baseProvider) IsNamed() bool {
@@ -21,20 +44,41 @@ func (p *baseProvider) Key() string {
 	return p.key
 }
 
+// Name returns the readable form of the key, used by logs and errors.
+func (p *baseProvider) Name() string {
+	return p.name
+}
+
+// Source returns where the provider was registered, as `file:line`.
+func (p *baseProvider) Source() string {
+	return p.source
+}
+
 type valueProvider[T any] struct {
 	baseProvider
-	instance T
+	instance  T
+	autoHooks bool
 }
 
 func (p *valueProvider[T]) Get(ctx InvokeCtx) (T, error) {
 	return p.instance, nil
 }
 
+func (p *valueProvider[T]) kind() string {
+	return "value"
+}
+
+func (p *valueProvider[T]) cached() bool {
+	return true
+}
+
 type lazyProvider[T any] struct {
 	baseProvider
-	instance T
-	factory  CtorFunc[T]
-	once     sync.Once
+	instance  T
+	factory   CtorFunc[T]
+	autoHooks bool
+	once      sync.Once
+	created   atomic.Bool
 }
 
 func (p *lazyProvider[T]) Get(ctx InvokeCtx) (T, error) {
@@ -45,12 +89,31 @@ func (p *lazyProvider[T]) Get(ctx InvokeCtx) (T, error) {
 			err = ferr
 			return
 		}
-		// Check register hook
+		if p.autoHooks {
+			registerAutoHooks(ctx, instance)
+		}
 		p.instance = instance
+		p.created.Store(true)
 	})
 	return p.instance, err
 }
 
+func (p *lazyProvider[T]) kind() string {
+	return "lazy"
+}
+
+func (p *lazyProvider[T]) cached() bool {
+	return p.created.Load()
+}
+
+func (p *lazyProvider[T]) fork() any {
+	return &lazyProvider[T]{
+		baseProvider: p.baseProvider,
+		factory:      p.factory,
+		autoHooks:    p.autoHooks,
+	}
+}
+
 type factoryProvider[T any] struct {
 	baseProvider
 	factory CtorFunc[T]
@@ -65,3 +128,31 @@ func (p *factoryProvider[T]) Get(ctx InvokeCtx) (T, error) {
 	}
 	return instance, nil
 }
+
+func (p *factoryProvider[T]) kind() string {
+	return "factory"
+}
+
+// providerGroup holds the providers registered under the same key by DUPLICATE_GROUP.
+type providerGroup struct {
+	items []any
+}
+
+func (g *providerGroup) kind() string {
+	return "group"
+}
+
+func (g *providerGroup) last() any {
+	return g.items[len(g.items)-1]
+}
+
+func (g *providerGroup) fork() any {
+	ng := &providerGroup{items: make([]any, len(g.items))}
+	for i, item := range g.items {
+		if fp, ok := item.(forkable); ok {
+			item = fp.fork()
+		}
+		ng.items[i] = item
+	}
+	return ng
+}
@@ -1,11 +1,19 @@
 package gdit
 
-import "github.com/saweima12/gdit/internal/utils"
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/saweima12/gdit/internal/utils"
+)
 
 const (
 	provider_lazy = iota
 	provider_factory
 	provider_value
+	provider_config
 )
 
 type ProviderBuilder[T any] interface {
@@ -13,10 +21,17 @@ type ProviderBuilder[T any] interface {
 	When(condition bool) ProviderBuilder[T]
 	// WhenFunc determines whether to register the provider based on a dynamic condition evaluated at runtime.
 	WhenFunc(condition func() bool) ProviderBuilder[T]
-	// WithName assigns a unique name to the provider for named dependency resolution.
+	// WithName assigns a name to the provider for named dependency resolution.
+	// The name only has to be unique among the providers of type T.
 	WithName(name string) ProviderBuilder[T]
+	// WithKey assigns the name carried by a typed key, see NewKey.
+	WithKey(key Key[T]) ProviderBuilder[T]
+	// WithoutAutoHooks keeps the container from registering hooks for the Starter, Stopper and io.Closer
+	// interfaces implemented by the instance. It has no effect on factory providers, which have no auto hooks.
+	WithoutAutoHooks() ProviderBuilder[T]
 	// Attach adds the configured provider to the specified container.
-	Attach(c Container)
+	// An error is returned if the container's duplicate policy rejects the provider.
+	Attach(c Container) error
 }
 
 func newProviderBuilder[T any](bType uint8) *providerBuilder[T] {
@@ -33,6 +48,10 @@ type providerBuilder[T any] struct {
 	name          string
 	instance      T
 	factory       CtorFunc[T]
+	// bind builds the instance of a config provider at Attach.
+	bind        func() (T, error)
+	reloadable  bool
+	noAutoHooks bool
 }
 
 func (b *providerBuilder[T]) WithName(name string) ProviderBuilder[T] {
@@ -40,6 +59,21 @@ func (b *providerBuilder[T]) WithName(name string) ProviderBuilder[T] {
 	return b
 }
 
+func (b *providerBuilder[T]) WithKey(key Key[T]) ProviderBuilder[T] {
+	b.name = key.name
+	return b
+}
+
+func (b *providerBuilder[T]) WithoutAutoHooks() ProviderBuilder[T] {
+	b.noAutoHooks = true
+	return b
+}
+
+func (b *providerBuilder[T]) Reloadable() ProviderBuilder[T] {
+	b.reloadable = true
+	return b
+}
+
 func (b *providerBuilder[T]) When(condition bool) ProviderBuilder[T] {
 	b.condition = condition
 	return b
@@ -50,35 +84,73 @@ func (b *providerBuilder[T]) WhenFunc(conditionFunc func() bool) ProviderBuilder
 	return b
 }
 
-func (b *providerBuilder[T]) Attach(c Container) {
-	logger := c.getLogger()
+func (b *providerBuilder[T]) Attach(c Container) error {
+	logger := c.getScope().Logger
 	if !b.shouldRegister() {
-		typeName := utils.GetType[T]()
-		logger.Debug("Provider of type %s not registered due to failing precondition checks.", typeName)
-		return
+		logger.log(LOG_DEBUG, "The provider is not registered due to failing precondition checks.",
+			Field{"scope", c.getScope().Name}, Field{"provider", utils.GetType[T]()})
+		return nil
 	}
-	p := b.getProvider()
-	c.AddProvider(p.Key(), p, p.IsNamed())
+	if b.bind != nil {
+		instance, err := b.bind()
+		if err != nil {
+			return err
+		}
+		b.instance = instance
+		logger.log(LOG_DEBUG, "The config is bound.", Field{"provider", utils.GetType[T]()}, Field{"value", Redact(instance)})
+	}
+	// A value carrying `validate` tags is checked like a config, there is no source to bind.
+	if b.buildType == provider_value && hasValidateRules(reflect.TypeOf((*T)(nil)).Elem()) {
+		if err := validateValue(utils.GetType[T](), reflect.ValueOf(&b.instance).Elem()); err != nil {
+			return err
+		}
+	}
+	p := b.getProvider(registrationSource())
+	stored, err := c.getScope().addProvider(p.Key(), p, p.IsNamed())
+	if err != nil || !stored {
+		return err
+	}
+	// The instance of a value exists already, its hooks are registered with it.
+	if vp, ok := p.(*valueProvider[T]); ok && vp.autoHooks {
+		recordAttach(c, vp)
+		return vp.attachAutoHooks(c)
+	}
+	return nil
 }
 
-func (b *providerBuilder[T]) getProvider() provider[T] {
+func (b *providerBuilder[T]) getProvider(source string) provider[T] {
 	key, named := utils.GetProviderKey[T](b.name)
+	base := baseProvider{key: key, name: utils.GetType[T](), named: named, source: source}
+	if named {
+		base.name = utils.GetNamedType[T](b.name)
+	}
 	switch b.buildType {
 	case provider_value:
 		return &valueProvider[T]{
 			instance:     b.instance,
-			baseProvider: baseProvider{named: named, key: key},
+			autoHooks:    !b.noAutoHooks,
+			baseProvider: base,
 		}
 	case provider_lazy:
 		return &lazyProvider[T]{
 			factory:      b.factory,
-			baseProvider: baseProvider{named: named, key: key},
+			autoHooks:    !b.noAutoHooks,
+			baseProvider: base,
 		}
 	case provider_factory:
 		return &factoryProvider[T]{
 			factory:      b.factory,
-			baseProvider: baseProvider{named: named, key: key},
+			baseProvider: base,
+		}
+	case provider_config:
+		p := &configProvider[T]{
+			bind:         b.bind,
+			reloadable:   b.reloadable,
+			baseProvider: base,
 		}
+		instance := b.instance
+		p.cur.Store(&instance)
+		return p
 	}
 	return nil
 }
@@ -92,3 +164,28 @@ func (b *providerBuilder[T]) shouldRegister() bool {
 	}
 	return true
 }
+
+var gditPkgPath = reflect.TypeOf(app{}).PkgPath()
+
+// registrationSource returns the location of the first caller outside of gdit,
+// so that duplicate registrations can point at the code that made them.
+func registrationSource() string {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		pkg := frame.Function
+		if i := strings.LastIndex(pkg, "/"); i >= 0 {
+			if j := strings.Index(pkg[i:], "."); j >= 0 {
+				pkg = pkg[:i+j]
+			}
+		}
+		if pkg != gditPkgPath && pkg != gditPkgPath+"/gditest" {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
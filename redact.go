@@ -0,0 +1,110 @@
+package gdit
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const redactedValue = "[REDACTED]"
+
+// Redact formats v like the %+v verb, replacing the fields tagged `secret:"true"` with [REDACTED].
+// The container formats configurations with it in its logs and errors, so secrets never
+// reach them, and it may be used the same way by application code.
+func Redact(v any) string {
+	var sb strings.Builder
+	writeRedacted(&sb, reflect.ValueOf(v), true, make(map[uintptr]struct{}))
+	return sb.String()
+}
+
+func isSecretField(field reflect.StructField) bool {
+	return field.Tag.Get("secret") == "true"
+}
+
+// writeRedacted writes v to sb. visiting holds the pointers and maps being written,
+// so a value referring to itself is written as <cycle> instead of recursing forever.
+func writeRedacted(sb *strings.Builder, v reflect.Value, top bool, visiting map[uintptr]struct{}) {
+	if !v.IsValid() {
+		sb.WriteString("<nil>")
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			sb.WriteString("<nil>")
+			return
+		}
+		if v.Kind() == reflect.Pointer {
+			if _, ok := visiting[v.Pointer()]; ok {
+				sb.WriteString("<cycle>")
+				return
+			}
+			visiting[v.Pointer()] = struct{}{}
+			defer delete(visiting, v.Pointer())
+			if top {
+				sb.WriteByte('&')
+			}
+		}
+		writeRedacted(sb, v.Elem(), false, visiting)
+	case reflect.Struct:
+		if v.Type() == timeType {
+			fmt.Fprintf(sb, "%v", v)
+			return
+		}
+		t := v.Type()
+		sb.WriteByte('{')
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if i > 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(field.Name)
+			sb.WriteByte(':')
+			if isSecretField(field) {
+				sb.WriteString(redactedValue)
+				continue
+			}
+			writeRedacted(sb, v.Field(i), false, visiting)
+		}
+		sb.WriteByte('}')
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			sb.WriteString("[]")
+			return
+		}
+		sb.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				sb.WriteByte(' ')
+			}
+			writeRedacted(sb, v.Index(i), false, visiting)
+		}
+		sb.WriteByte(']')
+	case reflect.Map:
+		if v.IsNil() {
+			sb.WriteString("map[]")
+			return
+		}
+		if _, ok := visiting[v.Pointer()]; ok {
+			sb.WriteString("<cycle>")
+			return
+		}
+		visiting[v.Pointer()] = struct{}{}
+		defer delete(visiting, v.Pointer())
+		sb.WriteString("map[")
+		iter := v.MapRange()
+		first := true
+		for iter.Next() {
+			if !first {
+				sb.WriteByte(' ')
+			}
+			first = false
+			fmt.Fprintf(sb, "%v:", iter.Key())
+			writeRedacted(sb, iter.Value(), false, visiting)
+		}
+		sb.WriteByte(']')
+	default:
+		fmt.Fprintf(sb, "%v", v)
+	}
+}
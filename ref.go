@@ -0,0 +1,144 @@
+package gdit
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/saweima12/gdit/internal/utils"
+)
+
+// Ref is a live handle on the instance registered for T. Unlike an instance captured
+// in a constructor, Load always returns the current instance, and the handle follows
+// the provider when it is replaced at runtime.
+type Ref[T any] struct {
+	container Container
+	key       string
+	name      string
+	named     bool
+
+	cur  atomic.Pointer[T]
+	mu   sync.Mutex
+	subs []func(prev, cur T)
+}
+
+// Load returns the instance currently registered for T.
+func (r *Ref[T]) Load() T {
+	return *r.cur.Load()
+}
+
+// OnChange registers a callback invoked after the instance is swapped, so that dependents
+// can rebuild the state derived from it. Callbacks run in registration order on the
+// goroutine performing the swap.
+func (r *Ref[T]) OnChange(f func(prev, cur T)) {
+	r.mu.Lock()
+	r.subs = append(r.subs, f)
+	r.mu.Unlock()
+}
+
+func (r *Ref[T]) store(instance T) {
+	prev := r.cur.Swap(&instance)
+	if prev == nil || sameInstance(*prev, instance) {
+		return
+	}
+
+	r.mu.Lock()
+	subs := make([]func(prev, cur T), len(r.subs))
+	copy(subs, r.subs)
+	r.mu.Unlock()
+	for _, f := range subs {
+		f(*prev, instance)
+	}
+}
+
+// refresh resolves the instance again from the container the handle was created in.
+func (r *Ref[T]) refresh() error {
+	ctx := getContext(r.container)
+	defer ctx.recycle()
+	instance, err := injectInternal[T](ctx, r.key, r.name, r.named)
+	if err != nil {
+		return err
+	}
+	r.store(instance)
+	return nil
+}
+
+// refresher is implemented by every Ref, whatever its type argument.
+type refresher interface {
+	refresh() error
+}
+
+type refKey struct {
+	container Container
+	key       string
+	named     bool
+}
+
+// InjectRef resolves a live handle on the dependency of type T.
+// [ctx] -> The context used for dependency resolution.
+// Returns a Ref whose Load follows the provider of T, and any error encountered during resolution.
+func InjectRef[T any](ctx Context) (*Ref[T], error) {
+	return injectRefInternal[T](ctx, utils.GetTypeKey[T](), utils.GetType[T](), false)
+}
+
+// InjectNamedRef resolves a live handle on the named dependency of type T.
+// [ctx] -> The context used for dependency resolution.
+// [name] -> The name identifying the dependency to be resolved.
+// Returns a Ref whose Load follows the named provider, and any error encountered during resolution.
+func InjectNamedRef[T any](ctx Context, name string) (*Ref[T], error) {
+	return injectRefInternal[T](ctx, utils.GetNamedKey[T](name), utils.GetNamedType[T](name), true)
+}
+
+// MustInjectRef resolves a live handle on the dependency of type T. Panics if resolution fails.
+// [ctx] -> The context used for dependency resolution.
+// Returns a Ref whose Load follows the provider of T.
+func MustInjectRef[T any](ctx Context) *Ref[T] {
+	ref, err := InjectRef[T](ctx)
+	if err != nil {
+		panic(fmt.Sprintf("MustInjectRef failed, err: %v", err))
+	}
+	return ref
+}
+
+func injectRefInternal[T any](ctx Context, key, name string, isNamed bool) (*Ref[T], error) {
+	c := ctx.getContainer()
+	root := c.getScope().root
+	rk := refKey{container: c, key: key, named: isNamed}
+	// A single handle is shared per container and key, so repeated injections do not pile up.
+	if ref, ok := root.refs.Load(rk); ok {
+		return ref.(*Ref[T]), nil
+	}
+
+	instance, err := injectInternal[T](ctx, key, name, isNamed)
+	if err != nil {
+		return nil, err
+	}
+	ref := &Ref[T]{container: c, key: key, name: name, named: isNamed}
+	ref.cur.Store(&instance)
+	actual, _ := root.refs.LoadOrStore(rk, ref)
+	return actual.(*Ref[T]), nil
+}
+
+// refreshRefs updates the handles following the given key.
+func (ap *app) refreshRefs(key string, isNamed bool) {
+	ap.refs.Range(func(k, value any) bool {
+		rk := k.(refKey)
+		if rk.key != key || rk.named != isNamed {
+			return true
+		}
+		if err := value.(refresher).refresh(); err != nil {
+			ap.Logger.log(LOG_ERROR, "Refresh the reference failed.", Field{"key", key}, Field{"error", err})
+		}
+		return true
+	})
+}
+
+// sameInstance reports whether a and b are the same comparable value.
+func sameInstance(a, b any) bool {
+	t := reflect.TypeOf(a)
+	if t == nil || t != reflect.TypeOf(b) || !t.Comparable() {
+		return false
+	}
+	return a == b
+}
@@ -0,0 +1,192 @@
+package gdit
+
+import (
+	stdctx "context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+)
+
+type pendingReload struct {
+	cfg  reloadableConfig
+	next any
+	prev any
+}
+
+func (ap *app) Reload(ctx stdctx.Context) error {
+	// The hooks are read under the scope locks, reloads only need to be serialized.
+	ap.reloadMu.Lock()
+	defer ap.reloadMu.Unlock()
+	if state := ap.CurState(); state != STATE_READY {
+		return fmt.Errorf("The app cannot be reloaded in state %v.", state)
+	}
+	ap.Logger.log(LOG_DEBUG, "The app is starting reload.")
+
+	// Read and validate every reloadable config before swapping any of them.
+	var pending []*pendingReload
+	var errs []error
+	ap.rangeScopes(func(sc *Scope) {
+		sc.rangeProviders(func(k string, p any, isNamed bool) {
+			cfg, ok := p.(reloadableConfig)
+			if !ok || !cfg.isReloadable() {
+				return
+			}
+			next, err := cfg.rebind()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			pending = append(pending, &pendingReload{cfg: cfg, next: next})
+		})
+	})
+	if len(errs) > 0 {
+		return fmt.Errorf("Reload aborted, the configs are unchanged: %w", errors.Join(errs...))
+	}
+
+	// Reload hooks run scope by scope, each ordered like its start hooks.
+	var hooks []reloadHook
+	var scopes []*Scope
+	for _, sc := range ap.orderedScopes() {
+		sc.mu.RLock()
+		ordered, err := orderReloadHooks(sc.Name, sc.reloadHooks)
+		sc.mu.RUnlock()
+		if err != nil {
+			return fmt.Errorf("Reload aborted, the configs are unchanged: %w", err)
+		}
+		for _, h := range ordered {
+			hooks = append(hooks, h)
+			scopes = append(scopes, sc)
+		}
+	}
+
+	for _, pr := range pending {
+		pr.prev = pr.cfg.swap(pr.next)
+		ap.refreshRefs(pr.cfg.Key(), pr.cfg.IsNamed())
+	}
+
+	for i := range hooks {
+		err := ctx.Err()
+		if err == nil {
+			err = ap.runReloadHook(scopes[i], hooks[i])
+		}
+		if err != nil {
+			err = fmt.Errorf("Reload hook of %s failed, err: %w", hookLabel(hooks[i].owner, hooks[i].name), err)
+			return ap.rollbackReload(pending, hooks[:i], scopes[:i], err)
+		}
+	}
+	ap.Logger.log(LOG_INFO, "The app is reloaded.")
+	return nil
+}
+
+func (ap *app) runReloadHook(sc *Scope, h reloadHook) (err error) {
+	// Resolved through the app like the start hooks, so the hooks of a fork see the providers of its parent.
+	ctx := getContext(ap.scopeContainer(sc))
+	defer ctx.recycle()
+	if sc.observed() {
+		done := observeHook(sc, sc.Name, h.owner, h.name, PHASE_RELOAD)
+		defer func() {
+			// A panicking reload hook is reported, then left to crash the caller like a start hook.
+			if r := recover(); r != nil {
+				done(fmt.Errorf("%v", r), true)
+				panic(r)
+			}
+			done(err, false)
+		}()
+	}
+	return h.fn(ctx)
+}
+
+// rollbackReload restores the previous configs and runs the hooks that already
+// completed again, so their derived state follows the restored values.
+func (ap *app) rollbackReload(pending []*pendingReload, done []reloadHook, scopes []*Scope, cause error) error {
+	ap.Logger.log(LOG_ERROR, "Reload failed, rolling back.", Field{"error", cause})
+	for _, pr := range pending {
+		pr.cfg.swap(pr.prev)
+		ap.refreshRefs(pr.cfg.Key(), pr.cfg.IsNamed())
+	}
+
+	errs := []error{cause}
+	for i := len(done) - 1; i >= 0; i-- {
+		if err := ap.runReloadHook(scopes[i], done[i]); err != nil {
+			errs = append(errs, fmt.Errorf("Rollback of the reload hook of %s failed, err: %w", hookLabel(done[i].owner, done[i].name), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ReloadOnSignal reloads the app whenever one of the signals is received, until ctx is done.
+// [ctx] -> Controls how long the signals are watched.
+// [a] -> The app to reload.
+// [sigs] -> The signals to watch, SIGHUP when none is given.
+// Returns an error if no signal is given and the platform has no SIGHUP.
+func ReloadOnSignal(ctx stdctx.Context, a App, sigs ...os.Signal) error {
+	if len(sigs) == 0 {
+		sigs = defaultReloadSignals
+	}
+	if len(sigs) == 0 {
+		return errors.New("No reload signal is available on this platform.")
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-ch:
+				a.getScope().Logger.log(LOG_INFO, "The signal is received, reloading the app.", Field{"signal", sig})
+				reloadAndLog(ctx, a)
+			}
+		}
+	}()
+	return nil
+}
+
+// ReloadOnFileChange reloads the app whenever the modification time of the file changes,
+// polling it at the given interval until ctx is done.
+// [ctx] -> Controls how long the file is watched.
+// [a] -> The app to reload.
+// [path] -> The file to watch, usually a JSON file read by FromJSONFile.
+// [interval] -> How often the file is checked.
+// Returns an error if the file cannot be read.
+func ReloadOnFileChange(ctx stdctx.Context, a App, path string, interval time.Duration) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		lastMod := info.ModTime()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				info, err := os.Stat(path)
+				if err != nil {
+					a.getScope().Logger.log(LOG_WARN, "The watched file cannot be read.", Field{"path", path}, Field{"error", err})
+					continue
+				}
+				if info.ModTime().Equal(lastMod) {
+					continue
+				}
+				lastMod = info.ModTime()
+				a.getScope().Logger.log(LOG_INFO, "The file changed, reloading the app.", Field{"path", path})
+				reloadAndLog(ctx, a)
+			}
+		}
+	}()
+	return nil
+}
+
+func reloadAndLog(ctx stdctx.Context, a App) {
+	if err := a.Reload(ctx); err != nil {
+		a.getScope().Logger.log(LOG_ERROR, "Reload failed.", Field{"error", err})
+	}
+}
@@ -0,0 +1,7 @@
+//go:build windows || plan9 || js || wasip1
+
+package gdit
+
+import "os"
+
+var defaultReloadSignals []os.Signal
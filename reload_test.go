@@ -0,0 +1,190 @@
+package gdit_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/saweima12/gdit"
+)
+
+type testLimitConfig struct {
+	Rate int `default:"10" validate:"min=1"`
+}
+
+type testLimiter struct {
+	rate int
+}
+
+func getReloadApp(failOn int) (gdit.App, *testLimiter) {
+	app := gdit.New()
+	gdit.ProvideConfig[*testLimitConfig](gdit.FromEnv("RELOAD")).Reloadable().Attach(app)
+
+	limiter, _ := gdit.Invoke(app, func(ctx gdit.InvokeCtx) (*testLimiter, error) {
+		cfg := gdit.MustInjectRef[*testLimitConfig](ctx)
+		limiter := &testLimiter{rate: cfg.Load().Rate}
+		ctx.OnReload(func(reloadCtx gdit.ReloadCtx) error {
+			if cfg.Load().Rate == failOn {
+				return errors.New("rejected")
+			}
+			limiter.rate = cfg.Load().Rate
+			return nil
+		})
+		return limiter, nil
+	})
+	app.Startup()
+	return app, limiter
+}
+
+func TestReload(t *testing.T) {
+	t.Run("The hooks should see the new config", func(ct *testing.T) {
+		ct.Setenv("RELOAD_RATE", "20")
+		app, limiter := getReloadApp(-1)
+		defer app.Teardown()
+
+		ct.Setenv("RELOAD_RATE", "30")
+		if err := app.Reload(context.Background()); err != nil {
+			ct.Fatal(err)
+		}
+		if limiter.rate != 30 {
+			ct.Errorf("unexpected rate %d", limiter.rate)
+		}
+	})
+
+	t.Run("An invalid config should not be swapped in", func(ct *testing.T) {
+		ct.Setenv("RELOAD_RATE", "20")
+		app, limiter := getReloadApp(-1)
+		defer app.Teardown()
+
+		ct.Setenv("RELOAD_RATE", "0")
+		if err := app.Reload(context.Background()); err == nil {
+			ct.Fail()
+		}
+		cfg, _ := gdit.Invoke(app, func(ctx gdit.InvokeCtx) (*testLimitConfig, error) {
+			return gdit.Inject[*testLimitConfig](ctx)
+		})
+		if limiter.rate != 20 || cfg.Rate != 20 {
+			ct.Fail()
+		}
+	})
+
+	t.Run("A failing hook should roll the config back", func(ct *testing.T) {
+		ct.Setenv("RELOAD_RATE", "20")
+		app, limiter := getReloadApp(40)
+		defer app.Teardown()
+
+		ct.Setenv("RELOAD_RATE", "40")
+		if err := app.Reload(context.Background()); err == nil {
+			ct.Fail()
+		}
+		cfg, _ := gdit.Invoke(app, func(ctx gdit.InvokeCtx) (*testLimitConfig, error) {
+			return gdit.Inject[*testLimitConfig](ctx)
+		})
+		if limiter.rate != 20 || cfg.Rate != 20 {
+			ct.Fail()
+		}
+	})
+}
+
+func TestReloadHooks(t *testing.T) {
+	t.Run("The hooks should run by priority and constraints", func(ct *testing.T) {
+		rec := &testRecorder{}
+		app := gdit.New()
+		for _, h := range []struct {
+			name string
+			opts []gdit.HookOption
+		}{
+			{"cache", []gdit.HookOption{gdit.WithHookName("cache"), gdit.After("limiter")}},
+			{"limiter", []gdit.HookOption{gdit.WithHookName("limiter")}},
+			{"metrics", []gdit.HookOption{gdit.WithPriority(10)}},
+		} {
+			name := h.name
+			gdit.InvokeFunc(app, func(ctx gdit.InvokeCtx) error {
+				ctx.OnReload(func(reloadCtx gdit.ReloadCtx) error {
+					rec.record("reload:" + name)
+					return nil
+				}, h.opts...)
+				return nil
+			})
+		}
+		app.Startup()
+		defer app.Teardown()
+
+		if err := app.Reload(context.Background()); err != nil {
+			ct.Fatal(err)
+		}
+		if rec.String() != "reload:metrics,reload:limiter,reload:cache" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+
+	t.Run("A replaced instance should no longer be reloaded", func(ct *testing.T) {
+		rec := &testRecorder{}
+		newLimiter := func(name string) gdit.CtorFunc[*testLimiter] {
+			return func(ctx gdit.InvokeCtx) (*testLimiter, error) {
+				ctx.OnReload(func(reloadCtx gdit.ReloadCtx) error {
+					rec.record("reload:" + name)
+					return nil
+				})
+				return &testLimiter{}, nil
+			}
+		}
+		app := gdit.New()
+		gdit.Provide[*testLimiter](newLimiter("old")).Attach(app)
+		gdit.InvokeFunc(app, func(ctx gdit.InvokeCtx) error {
+			_, err := gdit.Inject[*testLimiter](ctx)
+			return err
+		})
+		app.Startup()
+		defer app.Teardown()
+
+		if err := gdit.Replace(app, gdit.Provide[*testLimiter](newLimiter("new"))); err != nil {
+			ct.Fatal(err)
+		}
+		app.Reload(context.Background())
+		if rec.String() != "reload:new" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+}
+
+func TestReloadOnFileChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "limit.json")
+	os.WriteFile(path, []byte(`{"Rate": 20}`), 0o600)
+
+	app := gdit.New()
+	gdit.ProvideConfig[*testLimitConfig](gdit.FromJSONFile(path)).Reloadable().Attach(app)
+	reloaded := make(chan int, 1)
+	gdit.InvokeFunc(app, func(ctx gdit.InvokeCtx) error {
+		ctx.OnReload(func(reloadCtx gdit.ReloadCtx) error {
+			reloaded <- gdit.MustInject[*testLimitConfig](reloadCtx).Rate
+			return nil
+		})
+		return nil
+	})
+	app.Startup()
+	defer app.Teardown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := gdit.ReloadOnFileChange(ctx, app, path, 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	os.WriteFile(path, []byte(`{"Rate": 50}`), 0o600)
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Second))
+
+	t.Run("The file change should trigger a reload", func(ct *testing.T) {
+		select {
+		case rate := <-reloaded:
+			if rate != 50 {
+				ct.Errorf("unexpected rate %d", rate)
+			}
+		case <-time.After(2 * time.Second):
+			ct.Fail()
+		}
+	})
+}
@@ -0,0 +1,10 @@
+//go:build !windows && !plan9 && !js && !wasip1
+
+package gdit
+
+import (
+	"os"
+	"syscall"
+)
+
+var defaultReloadSignals = []os.Signal{syscall.SIGHUP}
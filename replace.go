@@ -0,0 +1,163 @@
+package gdit
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ReplaceOption configures a call to Replace.
+type ReplaceOption func(opts *replaceOptions)
+
+type replaceOptions struct {
+	drain time.Duration
+}
+
+// WithDrain delays the stop hooks of the replaced instance by d, giving the callers
+// still holding it time to finish. Replace returns without waiting for them, their
+// errors are logged. Teardown runs the pending stop hooks right away.
+func WithDrain(d time.Duration) ReplaceOption {
+	return func(opts *replaceOptions) {
+		opts.drain = d
+	}
+}
+
+// Replace atomically swaps the provider registered in c for the one built by pb,
+// while the app is running. The new instance is created and its OnStart hook runs first,
+// so resolutions keep using the old instance until the new one is ready. The OnStop hooks
+// of the old instance then run once the drain period is over, see WithDrain.
+// [c] -> Container holding the provider to replace.
+// [pb] -> Builder of the new provider, created by Provide, ProvideFactory or ProvideValue.
+// Returns an error if the provider is not registered in c, or if the new instance fails to start.
+func Replace[T any](c Container, pb ProviderBuilder[T], opts ...ReplaceOption) error {
+	b, ok := pb.(*providerBuilder[T])
+	if !ok {
+		return errors.New("Replace requires a builder created by Provide, ProvideFactory or ProvideValue.")
+	}
+	if state := c.CurState(); state != STATE_READY {
+		return fmt.Errorf("The provider cannot be replaced in state %v, use Attach before startup.", state)
+	}
+	ro := replaceOptions{}
+	for _, opt := range opts {
+		opt(&ro)
+	}
+
+	p := b.getProvider(registrationSource())
+	sc := c.getScope()
+	if err := checkReserved(sc, p.Key(), p, p.IsNamed()); err != nil {
+		return err
+	}
+	if !sc.hasProvider(p.Key(), p.IsNamed()) {
+		return fmt.Errorf("[%s] -> The provider [%s] is not registered.", sc.Name, p.Name())
+	}
+
+	// Factories create their instances on demand, the others are started before the swap.
+	if _, isFactory := p.(*factoryProvider[T]); !isFactory {
+		ctx := getContext(c)
+		defer ctx.recycle()
+		ctx.owner = newComponent(p.Name(), p)
+		if _, err := p.Get(ctx); err != nil {
+			return fmt.Errorf("Replace %s failed, err: %w", p.Name(), err)
+		}
+		if vp, ok := p.(*valueProvider[T]); ok && vp.autoHooks {
+			registerAutoHooks(ctx, vp.instance)
+		}
+		if err := ctx.tryAddOrRunHook(); err != nil {
+			return fmt.Errorf("Execution of the startup hook for the %s failed. err: %w", p.Name(), err)
+		}
+	}
+
+	prev, _ := sc.replaceProvider(p.Key(), p, p.IsNamed())
+	sc.Logger.log(LOG_INFO, "The provider is replaced.", providerFields(sc, p.Key(), p)...)
+	sc.root.refreshRefs(p.Key(), p.IsNamed())
+
+	// Collect the stop hooks of the previous provider from every scope it was resolved in.
+	// Its reload hooks are dropped, the replaced instance no longer follows the configs.
+	prevs := []any{prev}
+	if g, ok := prev.(*providerGroup); ok {
+		prevs = g.items
+	}
+	var hooks []stopHook
+	for _, item := range prevs {
+		sc.root.rangeScopes(func(s *Scope) {
+			hooks = append(hooks, s.takeStopHooks(item)...)
+			s.takeReloadHooks(func(h reloadHook) bool {
+				return h.owner != nil && h.owner.provider == item
+			})
+		})
+	}
+	sc.root.replaceRecorded(c, prevs, p)
+	return sc.root.scheduleDrain(c, hooks, ro.drain)
+}
+
+// drain runs the stop hooks of a replaced provider once.
+type drain struct {
+	once  sync.Once
+	timer *time.Timer
+	run   func() []*HookError
+}
+
+func (d *drain) flush() []*HookError {
+	var errs []*HookError
+	d.once.Do(func() {
+		errs = d.run()
+	})
+	return errs
+}
+
+func (ap *app) scheduleDrain(c Container, hooks []stopHook, wait time.Duration) error {
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	d := &drain{}
+	d.run = func() []*HookError {
+		ap.drainMu.Lock()
+		delete(ap.drains, d)
+		ap.drainMu.Unlock()
+
+		ctx := getContext(c)
+		defer ctx.recycle()
+		var errs []*HookError
+		for i := len(hooks) - 1; i >= 0; i-- {
+			if herr := hooks[i].run(c.getScope().Name, ctx); herr != nil {
+				errs = append(errs, herr)
+			}
+		}
+		return errs
+	}
+	if wait <= 0 {
+		return newLifecycleError("drain", d.flush())
+	}
+
+	ap.drainMu.Lock()
+	if ap.drains == nil {
+		ap.drains = make(map[*drain]struct{})
+	}
+	ap.drains[d] = struct{}{}
+	d.timer = time.AfterFunc(wait, func() {
+		if err := newLifecycleError("drain", d.flush()); err != nil {
+			ap.Logger.log(LOG_ERROR, "The replaced provider failed to drain.", Field{"error", err})
+		}
+	})
+	ap.drainMu.Unlock()
+	return nil
+}
+
+// flushDrains stops the pending drains and runs their hooks immediately.
+func (ap *app) flushDrains() []*HookError {
+	ap.drainMu.Lock()
+	pending := make([]*drain, 0, len(ap.drains))
+	for d := range ap.drains {
+		pending = append(pending, d)
+	}
+	ap.drainMu.Unlock()
+
+	var errs []*HookError
+	for _, d := range pending {
+		d.timer.Stop()
+		errs = append(errs, d.flush()...)
+	}
+	return errs
+}
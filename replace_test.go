@@ -0,0 +1,150 @@
+package gdit_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/saweima12/gdit"
+)
+
+type testClient struct {
+	endpoint string
+	started  atomic.Bool
+	stopped  atomic.Bool
+}
+
+func newTestClientCtor(endpoint string) gdit.CtorFunc[*testClient] {
+	return func(ctx gdit.InvokeCtx) (*testClient, error) {
+		client := &testClient{endpoint: endpoint}
+		ctx.OnStart(func(startCtx gdit.StartCtx) error {
+			client.started.Store(true)
+			return nil
+		})
+		ctx.OnStop(func(stopCtx gdit.StopCtx) error {
+			client.stopped.Store(true)
+			return nil
+		})
+		return client, nil
+	}
+}
+
+func injectClient(app gdit.Container) *testClient {
+	client, _ := gdit.Invoke(app, func(ctx gdit.InvokeCtx) (*testClient, error) {
+		return gdit.Inject[*testClient](ctx)
+	})
+	return client
+}
+
+func TestReplace(t *testing.T) {
+	app := gdit.New()
+	gdit.Provide[*testClient](newTestClientCtor("v1")).Attach(app)
+	old := injectClient(app)
+
+	t.Run("Replace should fail before startup", func(ct *testing.T) {
+		if gdit.Replace(app, gdit.Provide[*testClient](newTestClientCtor("v2"))) == nil {
+			ct.Fail()
+		}
+	})
+
+	app.Startup()
+
+	t.Run("The new instance should be started and the old one stopped", func(ct *testing.T) {
+		if err := gdit.Replace(app, gdit.Provide[*testClient](newTestClientCtor("v2"))); err != nil {
+			ct.Fatal(err)
+		}
+		cur := injectClient(app)
+		if cur.endpoint != "v2" || !cur.started.Load() || cur.stopped.Load() {
+			ct.Fail()
+		}
+		if !old.stopped.Load() {
+			ct.Fail()
+		}
+	})
+
+	t.Run("The old instance should be stopped after the drain period", func(ct *testing.T) {
+		prev := injectClient(app)
+		err := gdit.Replace(app, gdit.Provide[*testClient](newTestClientCtor("v3")), gdit.WithDrain(50*time.Millisecond))
+		if err != nil {
+			ct.Fatal(err)
+		}
+		if prev.stopped.Load() {
+			ct.Error("the old instance should keep running during the drain period")
+		}
+		time.Sleep(200 * time.Millisecond)
+		if !prev.stopped.Load() {
+			ct.Error("the old instance should be stopped once drained")
+		}
+	})
+
+	t.Run("A failing start hook should be wrapped by the error", func(ct *testing.T) {
+		errStart := errors.New("dial failed")
+		err := gdit.Replace(app, gdit.Provide[*testClient](func(ctx gdit.InvokeCtx) (*testClient, error) {
+			ctx.OnStart(func(startCtx gdit.StartCtx) error {
+				return errStart
+			})
+			return &testClient{endpoint: "broken"}, nil
+		}))
+		if !errors.Is(err, errStart) || injectClient(app).endpoint == "broken" {
+			ct.Errorf("unexpected error %v", err)
+		}
+	})
+
+	t.Run("Teardown should stop an instance still draining", func(ct *testing.T) {
+		prev := injectClient(app)
+		err := gdit.Replace(app, gdit.Provide[*testClient](newTestClientCtor("v4")), gdit.WithDrain(time.Hour))
+		if err != nil {
+			ct.Fatal(err)
+		}
+		cur := injectClient(app)
+		app.Teardown()
+		if !prev.stopped.Load() || !cur.stopped.Load() {
+			ct.Fail()
+		}
+	})
+}
+
+func TestRef(t *testing.T) {
+	app := gdit.New()
+	gdit.Provide[*testClient](newTestClientCtor("v1")).Attach(app)
+	app.Startup()
+	defer app.Teardown()
+
+	ref, err := gdit.Invoke(app, func(ctx gdit.InvokeCtx) (*gdit.Ref[*testClient], error) {
+		return gdit.InjectRef[*testClient](ctx)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var changed [2]string
+	ref.OnChange(func(prev, cur *testClient) {
+		changed = [2]string{prev.endpoint, cur.endpoint}
+	})
+
+	t.Run("The ref should load the current instance", func(ct *testing.T) {
+		if ref.Load().endpoint != "v1" {
+			ct.Fail()
+		}
+	})
+
+	t.Run("The ref should follow the replacement and notify", func(ct *testing.T) {
+		gdit.Replace(app, gdit.Provide[*testClient](newTestClientCtor("v2")))
+		if ref.Load().endpoint != "v2" || ref.Load() != injectClient(app) {
+			ct.Fail()
+		}
+		if changed != [2]string{"v1", "v2"} {
+			ct.Errorf("unexpected change %v", changed)
+		}
+	})
+
+	t.Run("The ref should be shared by the container", func(ct *testing.T) {
+		gdit.InvokeFunc(app, func(ctx gdit.InvokeCtx) error {
+			if gdit.MustInjectRef[*testClient](ctx) != ref {
+				ct.Fail()
+			}
+			return nil
+		})
+	})
+}
@@ -0,0 +1,210 @@
+package gdit
+
+import (
+	stdctx "context"
+	"errors"
+	"fmt"
+)
+
+// recordedInvoke is an Invoke call replayed by Reset to register the hooks again.
+// An attached value records its provider instead, Reset attaches the auto hooks of
+// the provider only while it is still registered.
+type recordedInvoke struct {
+	container Container
+	replay    func() error
+	provider  autoHookedProvider
+}
+
+// autoHookedProvider is implemented by the value providers, whose auto hooks are registered when attached.
+type autoHookedProvider interface {
+	keyedProvider
+	attachAutoHooks(c Container) error
+}
+
+// invokeMark returns the position of the next recorded invoke, recordInvoke takes it once the call succeeded.
+func invokeMark(c Container) int {
+	root := c.getScope().root
+	root.invokeMu.Lock()
+	defer root.invokeMu.Unlock()
+	return len(root.invokes)
+}
+
+// recordInvoke records an Invoke call made on c while the app is being wired, before Startup.
+// It is called once the call succeeded, a failed one is not run again. The invokes recorded
+// since mark were nested in the call, they are dropped since its replay runs them again.
+func recordInvoke(c Container, mark int, replay func() error) {
+	record(c, mark, recordedInvoke{container: c, replay: replay})
+}
+
+// recordAttach records a value attached to c before Startup. A nested attach is kept,
+// the replay of the invoke keeps the provider registered first and skips its auto hooks.
+func recordAttach(c Container, p autoHookedProvider) {
+	record(c, -1, recordedInvoke{container: c, provider: p})
+}
+
+func record(c Container, mark int, inv recordedInvoke) {
+	if c.CurState() != STATE_UNINITIALIZED {
+		return
+	}
+	root := c.getScope().root
+	// The invokes nested in a replayed one run with it.
+	if root.replaying.Load() {
+		return
+	}
+	root.invokeMu.Lock()
+	defer root.invokeMu.Unlock()
+	if mark >= 0 && mark <= len(root.invokes) {
+		kept := root.invokes[:mark]
+		for _, nested := range root.invokes[mark:] {
+			if nested.provider != nil {
+				kept = append(kept, nested)
+			}
+		}
+		root.invokes = kept
+	}
+	root.invokes = append(root.invokes, inv)
+}
+
+// replaceRecorded points the attaches recorded for the replaced providers to p, so Reset registers
+// the auto hooks of the instance in use. They are dropped when p is not a value.
+func (ap *app) replaceRecorded(c Container, prevs []any, p any) {
+	next, isValue := p.(autoHookedProvider)
+	ap.invokeMu.Lock()
+	defer ap.invokeMu.Unlock()
+
+	found := false
+	kept := ap.invokes[:0]
+	for _, inv := range ap.invokes {
+		if inv.provider != nil && containsProvider(prevs, inv.provider) {
+			// A replaced group leaves a single provider, recorded once.
+			if found || !isValue {
+				found = true
+				continue
+			}
+			found = true
+			inv.provider = next
+		}
+		kept = append(kept, inv)
+	}
+	// A lazy provider replaced by a value had nothing recorded.
+	if !found && isValue {
+		kept = append(kept, recordedInvoke{container: c, provider: next})
+	}
+	ap.invokes = kept
+}
+
+func (ap *app) Reset() error {
+	ap.lifeMu.Lock()
+	defer ap.lifeMu.Unlock()
+
+	if state := ap.CurState(); state != STATE_TERMINATED && state != STATE_FAILED {
+		return fmt.Errorf("The app cannot be reset in state %v, call Teardown first.", state)
+	}
+	ap.Logger.log(LOG_DEBUG, "The app is resetting.")
+
+	// A failed startup may leave replaced providers draining.
+	ap.flushDrains()
+	sealed := ap.snapshot.Load() != nil
+	var errs []error
+	ap.rangeScopes(func(sc *Scope) {
+		sc.resetHooks()
+		// Lazy singletons are discarded, the next resolution creates them again.
+		// The configs are bound again from their sources.
+		sc.rangeProviders(func(k string, p any, isNamed bool) {
+			switch rp := p.(type) {
+			case forkable:
+				sc.replaceProvider(k, rp.fork(), isNamed)
+			case resettableConfig:
+				next, err := rp.reset()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("[%s] -> The config [%s] cannot be bound again, err: %w", sc.Name, rp.Name(), err))
+					return
+				}
+				sc.replaceProvider(k, next, isNamed)
+			}
+		})
+		// The replayed invokes may register providers, an auto sealed app is sealed again by Startup.
+		sc.unseal()
+		sc.changeState(STATE_UNINITIALIZED)
+	})
+	ap.forked.Range(func(k, value any) bool {
+		ap.forked.Delete(k)
+		return true
+	})
+	ap.refs.Range(func(k, value any) bool {
+		ap.refs.Delete(k)
+		return true
+	})
+	ap.shutdown.Store(newShutdownState())
+
+	// The constructors run again to register their hooks.
+	ap.invokeMu.Lock()
+	invokes := ap.invokes
+	ap.invokes = nil
+	ap.invokeMu.Unlock()
+
+	ap.replaying.Store(true)
+	defer ap.replaying.Store(false)
+
+	kept := invokes[:0]
+	for _, inv := range invokes {
+		// The invokes made on a closed scope are dropped.
+		if sc := inv.container.getScope(); sc != ap.Scope {
+			if cur, ok := ap.subScopes.Load(sc.Name); !ok || cur != sc {
+				continue
+			}
+		}
+		if inv.provider != nil {
+			// The value attached first may have been replaced since, or dropped by the duplicate policy.
+			if !ownsProvider(inv.container.getScope(), inv.provider) {
+				continue
+			}
+			kept = append(kept, inv)
+			if err := inv.provider.attachAutoHooks(inv.container); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		kept = append(kept, inv)
+		if err := inv.replay(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	ap.invokeMu.Lock()
+	ap.invokes = kept
+	ap.invokeMu.Unlock()
+	if sealed && !ap.autoSeal {
+		ap.Seal()
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("Reset failed to bind the configs or replay the invokes, err: %w", errors.Join(errs...))
+	}
+	ap.Logger.log(LOG_DEBUG, "The app is reset.")
+	return nil
+}
+
+func (ap *app) Restart(ctx stdctx.Context) error {
+	var teardownErr error
+	if ap.CurState() == STATE_READY {
+		// A failing stop hook must not keep a supervisor from starting the app again.
+		if teardownErr = ap.Teardown(); teardownErr != nil {
+			ap.Logger.log(LOG_ERROR, "The app failed to tear down, restarting anyway.", Field{"error", teardownErr})
+		}
+	}
+	if err := ap.Reset(); err != nil {
+		return errors.Join(teardownErr, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return errors.Join(teardownErr, err)
+	}
+	return errors.Join(teardownErr, ap.Startup())
+}
+
+// resetHooks drops every hook of the scope.
+func (sc *Scope) resetHooks() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.startHooks = nil
+	sc.stopHooks = nil
+	sc.reloadHooks = nil
+}
@@ -0,0 +1,164 @@
+package gdit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/saweima12/gdit"
+)
+
+type testConn struct {
+	id int
+}
+
+func TestRestart(t *testing.T) {
+	rec := &testRecorder{}
+	app := gdit.New()
+	next := 0
+	gdit.Provide[*testConn](func(ctx gdit.InvokeCtx) (*testConn, error) {
+		next++
+		return &testConn{id: next}, nil
+	}).Attach(app)
+	addTestComponent(app, rec, "c1", false, false)
+	var conns []*testConn
+	gdit.InvokeFunc(app, func(ctx gdit.InvokeCtx) error {
+		conn, err := gdit.Inject[*testConn](ctx)
+		conns = append(conns, conn)
+		return err
+	})
+
+	t.Run("Reset should be refused while the app is running", func(ct *testing.T) {
+		app.Startup()
+		if app.Reset() == nil {
+			ct.Fail()
+		}
+	})
+
+	t.Run("Restart should run the hooks again with new lazy instances", func(ct *testing.T) {
+		if err := app.Restart(context.Background()); err != nil {
+			ct.Fatal(err)
+		}
+		if rec.String() != "start:c1,stop:c1,start:c1" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+		if len(conns) != 2 || conns[0] == conns[1] {
+			ct.Errorf("the lazy instance should be created again, got %v", conns)
+		}
+		if app.CurState() != gdit.STATE_READY {
+			ct.Errorf("unexpected state %v", app.CurState())
+		}
+	})
+
+	t.Run("The hooks should not pile up across restarts", func(ct *testing.T) {
+		if err := app.Restart(context.Background()); err != nil {
+			ct.Fatal(err)
+		}
+		app.Teardown()
+		if rec.String() != "start:c1,stop:c1,start:c1,stop:c1,start:c1,stop:c1" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+
+	t.Run("A failed app should be reset", func(ct *testing.T) {
+		rec := &testRecorder{}
+		app := gdit.New()
+		fail := true
+		gdit.InvokeFunc(app, func(ctx gdit.InvokeCtx) error {
+			ctx.OnStart(func(startCtx gdit.StartCtx) error {
+				if fail {
+					fail = false
+					return context.Canceled
+				}
+				rec.record("start")
+				return nil
+			})
+			return nil
+		})
+		if app.Startup() == nil || app.CurState() != gdit.STATE_FAILED {
+			ct.Fatalf("the startup should fail, state %v", app.CurState())
+		}
+		if err := app.Reset(); err != nil || app.CurState() != gdit.STATE_UNINITIALIZED {
+			ct.Fatalf("unexpected error %v in state %v", err, app.CurState())
+		}
+		if err := app.Startup(); err != nil || rec.String() != "start" {
+			ct.Errorf("unexpected error %v with events %s", err, rec)
+		}
+	})
+
+	t.Run("Restart should start the value registered by Replace", func(ct *testing.T) {
+		rec := &testRecorder{}
+		app := gdit.New()
+		gdit.ProvideValue(&testWorker{rec: rec, name: "a"}).Attach(app)
+		app.Startup()
+		if err := gdit.Replace(app, gdit.ProvideValue(&testWorker{rec: rec, name: "b"})); err != nil {
+			ct.Fatal(err)
+		}
+		if err := app.Restart(context.Background()); err != nil {
+			ct.Fatal(err)
+		}
+		app.Teardown()
+		if rec.String() != "start:a,start:b,stop:a,stop:b,start:b,stop:b" {
+			ct.Errorf("unexpected events %s", rec)
+		}
+	})
+
+	t.Run("The providers attached by an invoke should be kept on restart", func(ct *testing.T) {
+		for _, opt := range []gdit.Option{gdit.WithAutoSeal(), gdit.WithDuplicatePolicy(gdit.DUPLICATE_ERROR)} {
+			rec := &testRecorder{}
+			app := gdit.New(opt)
+			gdit.InvokeFunc(app, func(ctx gdit.InvokeCtx) error {
+				addTestComponent(app, rec, "c1", false, false)
+				return gdit.ProvideValue(&testConn{id: 1}).Attach(app)
+			})
+			app.Startup()
+			if err := app.Restart(context.Background()); err != nil {
+				ct.Fatal(err)
+			}
+			if _, err := gdit.Invoke(app, func(ctx gdit.InvokeCtx) (*testConn, error) {
+				return gdit.Inject[*testConn](ctx)
+			}); err != nil || app.CurState() != gdit.STATE_READY {
+				ct.Errorf("unexpected error %v in state %v", err, app.CurState())
+			}
+			app.Teardown()
+			if rec.String() != "start:c1,stop:c1,start:c1,stop:c1" {
+				ct.Errorf("unexpected events %s", rec)
+			}
+		}
+	})
+
+	t.Run("A failed invoke should not be replayed", func(ct *testing.T) {
+		app := gdit.New()
+		runs := 0
+		gdit.InvokeFunc(app, func(ctx gdit.InvokeCtx) error {
+			runs++
+			return context.Canceled
+		})
+		app.Startup()
+		if err := app.Restart(context.Background()); err != nil || runs != 1 {
+			ct.Errorf("unexpected error %v after %d runs", err, runs)
+		}
+		app.Teardown()
+	})
+
+	t.Run("Reset should bind the configs again", func(ct *testing.T) {
+		ct.Setenv("RESET_PORT", "9000")
+		ct.Setenv("RESET_DATABASE_HOST", "localhost")
+		ct.Setenv("TEST_TOKEN", "secret")
+		app := gdit.New()
+		if err := gdit.ProvideConfig[testAppConfig](gdit.FromEnv("RESET")).Attach(app); err != nil {
+			ct.Fatal(err)
+		}
+		app.Startup()
+		app.Teardown()
+		ct.Setenv("RESET_PORT", "9100")
+		if err := app.Reset(); err != nil {
+			ct.Fatal(err)
+		}
+		cfg, err := gdit.Invoke(app, func(ctx gdit.InvokeCtx) (testAppConfig, error) {
+			return gdit.Inject[testAppConfig](ctx)
+		})
+		if err != nil || cfg.Port != 9100 {
+			ct.Errorf("unexpected config %+v with error %v", cfg, err)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package gdit
+
+import (
+	stdctx "context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// RunResult reports why App.Run returned.
+type RunResult struct {
+	// Reason describes what triggered the shutdown, such as `signal interrupt`.
+	Reason string
+	// Err joins the startup, requested shutdown and teardown errors, if any.
+	Err error
+	// Forced is set when a second signal interrupted the teardown. The teardown may still be
+	// running in the background, holding the app, so the caller must exit the process.
+	Forced bool
+}
+
+// ExitCode returns the code main should exit with: 0 after a clean shutdown,
+// 1 if an error occurred, and 2 if the teardown was forced by a second signal.
+func (r RunResult) ExitCode() int {
+	switch {
+	case r.Forced:
+		return 2
+	case r.Err != nil:
+		return 1
+	default:
+		return 0
+	}
+}
+
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+func (ap *app) Run(ctx stdctx.Context) RunResult {
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, shutdownSignals...)
+	defer signal.Stop(sigCh)
+
+	if err := ap.Startup(); err != nil {
+		return RunResult{Reason: "startup failed", Err: err}
+	}
+
+	var res RunResult
+	select {
+	case sig := <-sigCh:
+		res.Reason = fmt.Sprintf("signal %v", sig)
+	case <-ctx.Done():
+		res.Reason = fmt.Sprintf("context done: %v", ctx.Err())
+	case <-ap.Done():
+		req := ap.Wait()
+		res.Reason, res.Err = fmt.Sprintf("shutdown requested by %s", req.Component), req.Err
+	}
+	ap.Logger.log(LOG_INFO, "The app is shutting down.", Field{"reason", res.Reason})
+
+	// The stop hooks see the deadline through StopCtx.Context, and the cancellation of a forced exit.
+	stopCtx, cancel := stdctx.WithCancel(stdctx.Background())
+	if ap.shutdownTimeout > 0 {
+		stopCtx, cancel = stdctx.WithTimeout(stdctx.Background(), ap.shutdownTimeout)
+	}
+	defer cancel()
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- ap.teardown(stopCtx)
+	}()
+
+	select {
+	case err := <-stopped:
+		if err != nil {
+			res.Err = errors.Join(res.Err, err)
+		}
+	case sig := <-sigCh:
+		res.Forced = true
+		res.Err = errors.Join(res.Err, fmt.Errorf("The teardown is interrupted by the signal %v.", sig))
+	case <-stopCtx.Done():
+		res.Err = errors.Join(res.Err, fmt.Errorf("The teardown timed out after %v.", ap.shutdownTimeout))
+	}
+	return res
+}
@@ -0,0 +1,107 @@
+package gdit_test
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/saweima12/gdit"
+)
+
+func getRunApp(onStart gdit.StartFunc, onStop gdit.StopFunc, opts ...gdit.Option) gdit.App {
+	app := gdit.New(opts...)
+	gdit.InvokeFunc(app, func(ctx gdit.InvokeCtx) error {
+		ctx.OnStart(onStart)
+		ctx.OnStop(onStop)
+		return nil
+	})
+	return app
+}
+
+func interruptSelf() error {
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		return err
+	}
+	return p.Signal(os.Interrupt)
+}
+
+func TestRun(t *testing.T) {
+	t.Run("A cancelled context should shut the app down cleanly", func(ct *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		stopped := false
+		app := getRunApp(func(startCtx gdit.StartCtx) error {
+			cancel()
+			return nil
+		}, func(stopCtx gdit.StopCtx) error {
+			stopped = true
+			return nil
+		})
+
+		res := app.Run(ctx)
+		if !stopped || res.Err != nil || res.ExitCode() != 0 {
+			ct.Errorf("unexpected result %+v", res)
+		}
+	})
+
+	t.Run("A slow teardown should be bounded by the timeout", func(ct *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		release := make(chan struct{})
+		defer close(release)
+		app := getRunApp(func(startCtx gdit.StartCtx) error {
+			cancel()
+			return nil
+		}, func(stopCtx gdit.StopCtx) error {
+			<-release
+			return nil
+		}, gdit.WithShutdownTimeout(20*time.Millisecond))
+
+		res := app.Run(ctx)
+		if res.Err == nil || res.Forced || res.ExitCode() != 1 {
+			ct.Errorf("unexpected result %+v", res)
+		}
+	})
+
+	t.Run("The stop hooks should see the shutdown deadline", func(ct *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var stopErr error
+		app := getRunApp(func(startCtx gdit.StartCtx) error {
+			cancel()
+			return nil
+		}, func(stopCtx gdit.StopCtx) error {
+			<-stopCtx.Context().Done()
+			stopErr = stopCtx.Context().Err()
+			return stopErr
+		}, gdit.WithShutdownTimeout(20*time.Millisecond))
+
+		app.Run(ctx)
+		// The timed out teardown completes once the hook gives up.
+		app.Teardown()
+		if stopErr != context.DeadlineExceeded {
+			ct.Errorf("unexpected error %v", stopErr)
+		}
+	})
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	t.Run("A second signal should force the exit", func(ct *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		app := getRunApp(func(startCtx gdit.StartCtx) error {
+			return interruptSelf()
+		}, func(stopCtx gdit.StopCtx) error {
+			interruptSelf()
+			<-release
+			return nil
+		})
+
+		res := app.Run(context.Background())
+		if !res.Forced || res.ExitCode() != 2 {
+			ct.Errorf("unexpected result %+v", res)
+		}
+	})
+}
@@ -4,43 +4,235 @@ import (
 	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type Scope struct {
-	parent     Container
-	Name       string
-	State      LifeState
-	Logger     *loggerWrapper
-	mu         sync.RWMutex
-	TypeMap    sync.Map
-	NamedMap   sync.Map
-	startHooks []StartFunc
-	stopHooks  []StopFunc
+	parent      Container
+	root        *app
+	Name        string
+	State       LifeState
+	Logger      *loggerWrapper
+	mu          sync.RWMutex
+	regMu       sync.Mutex
+	dupPolicy   DuplicatePolicy
+	TypeMap     sync.Map
+	NamedMap    sync.Map
+	snapshot    atomic.Pointer[providerSnapshot]
+	startHooks  []startHook
+	stopHooks   []stopHook
+	reloadHooks []reloadHook
 }
 
 func (sc *Scope) getLogger() Logger {
 	return sc.Logger
 }
 
-func (sc *Scope) AddProvider(k string, p any, isNamed bool) {
+func (sc *Scope) getScope() *Scope {
+	return sc
+}
+
+func (sc *Scope) AddProvider(k string, p any, isNamed bool) error {
+	_, err := sc.addProvider(k, p, isNamed)
+	return err
+}
+
+// addProvider registers p under k, it reports false if the duplicate policy ignored p.
+func (sc *Scope) addProvider(k string, p any, isNamed bool) (bool, error) {
+	if err := checkReserved(sc, k, p, isNamed); err != nil {
+		return false, err
+	}
+	name := providerName(k, p)
+	providerMap := &sc.TypeMap
 	if isNamed {
-		sc.storeProvider(k, p, isNamed, &sc.NamedMap)
-		sc.Logger.Debug("[%s] -> The provider [%s] is registered by name", sc.Name, k)
-	} else {
-		sc.storeProvider(k, p, isNamed, &sc.TypeMap)
-		sc.Logger.Debug("[%s] -> The provider [%s] is registered by type", sc.Name, k)
+		providerMap = &sc.NamedMap
+	}
+	stored, err := sc.storeProvider(k, name, p, providerMap)
+	if err != nil || !stored {
+		return false, err
 	}
+	sc.Logger.log(LOG_DEBUG, "The provider is registered.", providerFields(sc, k, p)...)
+	if sc.observed() {
+		sc.emit(ProviderRegistered{Scope: sc.Name, Name: name, Named: isNamed, Source: providerSource(p)})
+	}
+	return stored, nil
 }
 
-func (sc *Scope) storeProvider(k string, p any, isNamed bool, providerMap *sync.Map) {
-	if _, loaded := providerMap.Swap(k, p); loaded {
-		msg := fmt.Sprintf("[%s] -> The provider [%s] was overwritten.", sc.Name, k)
-		sc.Logger.Warn(msg)
+// storeProvider stores p under k according to the duplicate policy,
+// it reports false if the policy ignored p.
+func (sc *Scope) storeProvider(k, name string, p any, providerMap *sync.Map) (bool, error) {
+	sc.regMu.Lock()
+	defer sc.regMu.Unlock()
+
+	if sc.snapshot.Load() != nil {
+		return false, fmt.Errorf("[%s] -> The provider [%s] cannot be registered: %w", sc.Name, name, ErrSealed)
+	}
+
+	prev, loaded := providerMap.Load(k)
+	if !loaded {
+		providerMap.Store(k, p)
+		return true, nil
+	}
+	// The provider registered by the first run of a replayed invoke is kept, whatever the policy.
+	if sc.root != nil && sc.root.replaying.Load() {
+		return false, nil
+	}
+
+	switch sc.dupPolicy {
+	case DUPLICATE_ERROR:
+		return false, &DuplicateError{
+			Scope:  sc.Name,
+			Name:   name,
+			First:  providerSource(prev),
+			Second: providerSource(p),
+		}
+	case DUPLICATE_KEEP_FIRST:
+		sc.Logger.log(LOG_WARN, "The provider is ignored, keeping the one registered first.",
+			append(providerFields(sc, k, p), Field{"kept", providerSource(prev)})...)
+		return false, nil
+	case DUPLICATE_GROUP:
+		g, ok := prev.(*providerGroup)
+		if !ok {
+			g = &providerGroup{items: []any{prev}}
+		}
+		// Copy on write, resolutions may be ranging over the previous group.
+		items := make([]any, len(g.items), len(g.items)+1)
+		copy(items, g.items)
+		providerMap.Store(k, &providerGroup{items: append(items, p)})
+	default:
+		providerMap.Store(k, p)
+		sc.Logger.log(LOG_WARN, "The provider is overwritten by the one registered last.",
+			append(providerFields(sc, k, p), Field{"overwritten", providerSource(prev)})...)
+		// The overwritten instance can no longer be resolved, it is not started either.
+		if sc.root != nil {
+			sc.root.rangeScopes(func(s *Scope) {
+				s.dropProviderHooks(prev)
+			})
+		}
 	}
+	return true, nil
 }
 
-func (sc *Scope) GetProvider(k string, isNamed bool) (val any, ok bool) {
+// replaceProvider swaps the provider stored under k regardless of the duplicate policy,
+// updating the snapshot of a sealed scope. It returns the previous provider if any.
+func (sc *Scope) replaceProvider(k string, p any, isNamed bool) (any, bool) {
+	sc.regMu.Lock()
+	defer sc.regMu.Unlock()
+
+	providerMap := &sc.TypeMap
+	if isNamed {
+		providerMap = &sc.NamedMap
+	}
+	prev, loaded := providerMap.Swap(k, p)
+	if snap := sc.snapshot.Load(); snap != nil {
+		sc.snapshot.Store(snap.with(k, p, isNamed))
+	}
+	return prev, loaded
+}
+
+// rangeProviders calls f for every provider registered in the scope itself.
+func (sc *Scope) rangeProviders(f func(k string, p any, isNamed bool)) {
+	if snap := sc.snapshot.Load(); snap != nil {
+		for k, p := range snap.types {
+			f(k, p, false)
+		}
+		for k, p := range snap.named {
+			f(k, p, true)
+		}
+		return
+	}
+	sc.TypeMap.Range(func(key, value any) bool {
+		f(key.(string), value, false)
+		return true
+	})
+	sc.NamedMap.Range(func(key, value any) bool {
+		f(key.(string), value, true)
+		return true
+	})
+}
+
+// hasProvider reports whether k is registered in the scope itself, ignoring its parents.
+func (sc *Scope) hasProvider(k string, isNamed bool) bool {
+	_, ok := sc.ownProvider(k, isNamed)
+	return ok
+}
+
+// ownProvider returns the provider registered under k in the scope itself, ignoring its parents.
+func (sc *Scope) ownProvider(k string, isNamed bool) (any, bool) {
+	if snap := sc.snapshot.Load(); snap != nil {
+		return snap.get(k, isNamed)
+	}
+	providerMap := &sc.TypeMap
 	if isNamed {
+		providerMap = &sc.NamedMap
+	}
+	return providerMap.Load(k)
+}
+
+// ownsProvider reports whether p is registered in sc itself, alone or in a group.
+func ownsProvider(sc *Scope, p keyedProvider) bool {
+	cur, ok := sc.ownProvider(p.Key(), p.IsNamed())
+	if !ok {
+		return false
+	}
+	if g, isGroup := cur.(*providerGroup); isGroup {
+		return containsProvider(g.items, p)
+	}
+	return cur == any(p)
+}
+
+func containsProvider(items []any, p any) bool {
+	for _, item := range items {
+		if item == p {
+			return true
+		}
+	}
+	return false
+}
+
+// providerName returns the readable name of a provider, falling back to its key.
+func providerName(k string, p any) string {
+	if np, ok := p.(interface{ Name() string }); ok {
+		return np.Name()
+	}
+	return k
+}
+
+// providerFields returns the log attributes of a provider registered under k.
+func providerFields(sc *Scope, k string, p any) []Field {
+	return []Field{
+		{"scope", sc.Name},
+		{"provider", providerName(k, p)},
+		{"kind", providerKind(p)},
+		{"source", providerSource(p)},
+	}
+}
+
+// providerKind returns how a provider creates its instances, such as `lazy` or `value`.
+func providerKind(p any) string {
+	if kp, ok := p.(interface{ kind() string }); ok {
+		return kp.kind()
+	}
+	return "unknown"
+}
+
+// providerSource returns where a provider was registered.
+func providerSource(p any) string {
+	if g, ok := p.(*providerGroup); ok {
+		p = g.last()
+	}
+	if sp, ok := p.(interface{ Source() string }); ok {
+		return sp.Source()
+	}
+	return "unknown"
+}
+
+func (sc *Scope) GetProvider(k string, isNamed bool) (val any, ok bool) {
+	if snap := sc.snapshot.Load(); snap != nil {
+		if val, ok := snap.get(k, isNamed); ok {
+			return val, ok
+		}
+	} else if isNamed {
 		if val, ok := sc.NamedMap.Load(k); ok {
 			return val, ok
 		}
@@ -57,53 +249,272 @@ func (sc *Scope) GetProvider(k string, isNamed bool) (val any, ok bool) {
 	}
 }
 
+// unseal drops the snapshot of a sealed scope, so Reset can replay the registrations.
+// The maps are kept in step with the snapshot, they hold the same providers.
+func (sc *Scope) unseal() {
+	sc.regMu.Lock()
+	defer sc.regMu.Unlock()
+	sc.snapshot.Store(nil)
+}
+
+// seal freezes the registrations of the scope into an immutable snapshot,
+// further calls to AddProvider fail with ErrSealed.
+func (sc *Scope) seal() {
+	sc.regMu.Lock()
+	defer sc.regMu.Unlock()
+	if sc.snapshot.Load() != nil {
+		return
+	}
+
+	snap := &providerSnapshot{
+		types: make(map[string]any),
+		named: make(map[string]any),
+	}
+	sc.TypeMap.Range(func(key, value any) bool {
+		snap.types[key.(string)] = value
+		return true
+	})
+	sc.NamedMap.Range(func(key, value any) bool {
+		snap.named[key.(string)] = value
+		return true
+	})
+	sc.snapshot.Store(snap)
+	sc.Logger.log(LOG_DEBUG, "The scope is sealed.", Field{"scope", sc.Name})
+}
+
 func (sc *Scope) CurState() LifeState {
-	return sc.State
+	return LifeState(atomic.LoadUint32((*uint32)(&sc.State)))
 }
 
-func (sc *Scope) start(ctx StartCtx) error {
-	sc.mu.Lock()
-	defer sc.mu.Unlock()
+// runStartHooks runs the start hooks of the phase with a context on c, see orderStartHooks,
+// and records the owners of the completed ones in started. The lock is released while a hook
+// runs, so the hook can resolve providers registering hooks of their own. The hooks registered
+// meanwhile run in the same pass, including those of a phase that has already completed.
+func (sc *Scope) runStartHooks(c Container, phase LifecyclePhase, started map[*component]struct{}) error {
+	ctx := getContext(c)
+	defer ctx.recycle()
 
-	sc.Logger.Debug("The scope [%s] is starting initialization.", sc.Name)
-	sc.changeState(STATE_INITIALIZING)
-	for i := range sc.startHooks {
-		if err := sc.startHooks[i](ctx); err != nil {
+	for {
+		hooks := sc.takeStartHooks(phase)
+		if len(hooks) == 0 {
+			return nil
+		}
+		hooks, err := orderStartHooks(sc.Name, phase, hooks)
+		if err != nil {
 			return err
 		}
+		for _, h := range hooks {
+			begin := time.Now()
+			stops, err := h.run(ctx)
+			if err != nil {
+				sc.Logger.log(LOG_ERROR, "The hook failed.",
+					append(hookFields(sc.Name, h.owner, h.name, h.phase, time.Since(begin)), Field{"error", err})...)
+				return fmt.Errorf("[%s] -> The %v hook of %s failed, err: %w", sc.Name, h.phase, hookLabel(h.owner, h.name), err)
+			}
+			sc.Logger.log(LOG_DEBUG, "The hook has run.", hookFields(sc.Name, h.owner, h.name, h.phase, time.Since(begin))...)
+			sc.mu.Lock()
+			sc.stopHooks = append(sc.stopHooks, stops...)
+			sc.mu.Unlock()
+			started[h.owner] = struct{}{}
+		}
 	}
-	sc.Logger.Debug("The scope [%s] is ready.", sc.Name)
-	sc.changeState(STATE_READY)
-	return nil
 }
 
-func (sc *Scope) stop(ctx Context) error {
+// takeStartHooks marks and returns the start hooks that have not been taken yet, up to the phase.
+func (sc *Scope) takeStartHooks(phase LifecyclePhase) []startHook {
 	sc.mu.Lock()
 	defer sc.mu.Unlock()
 
-	sc.changeState(STATE_SHUTTING_DOWN)
-	for i := len(sc.stopHooks) - 1; i >= 0; i-- {
-		if err := sc.stopHooks[i](ctx); err != nil {
-			return err
+	var hooks []startHook
+	for i := range sc.startHooks {
+		if h := &sc.startHooks[i]; !h.taken && h.phase <= phase {
+			h.taken = true
+			hooks = append(hooks, *h)
 		}
 	}
-	sc.changeState(STATE_TERMINATED)
-	return nil
+	return hooks
 }
 
-func (sc *Scope) addStartHook(f StartFunc) {
+// collectStartOwners adds the owners of the start hooks of the scope to owners.
+func (sc *Scope) collectStartOwners(owners map[*component]struct{}) {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	for _, h := range sc.startHooks {
+		owners[h.owner] = struct{}{}
+	}
+}
+
+// runStopHooks removes the stop hooks of the phase from the scope and runs them, see orderStopHooks,
+// except those whose owner is skipped. Like runStartHooks, the lock is released while a hook runs,
+// and the stop hooks registered meanwhile run as well.
+func (sc *Scope) runStopHooks(ctx StopCtx, phase LifecyclePhase, skip func(owner *component) bool) []*HookError {
+	var errs []*HookError
+	for {
+		hooks := sc.takePhaseStopHooks(phase)
+		if len(hooks) == 0 {
+			return errs
+		}
+		hooks, err := orderStopHooks(sc.Name, phase, hooks)
+		if err != nil {
+			// Every stop hook must still run, in the default order.
+			sc.Logger.log(LOG_ERROR, "The stop hooks are run in the default order.", Field{"scope", sc.Name}, Field{"error", err})
+		}
+		for _, h := range hooks {
+			if skip != nil && skip(h.owner) {
+				continue
+			}
+			begin := time.Now()
+			if herr := h.run(sc.Name, ctx); herr != nil {
+				sc.Logger.log(LOG_ERROR, "The hook failed.", append(herr.fields(), Field{"duration", time.Since(begin)})...)
+				errs = append(errs, herr)
+				continue
+			}
+			sc.Logger.log(LOG_DEBUG, "The hook has run.", hookFields(sc.Name, h.owner, h.name, h.phase, time.Since(begin))...)
+		}
+	}
+}
+
+// takePhaseStopHooks removes and returns the stop hooks up to the phase.
+func (sc *Scope) takePhaseStopHooks(phase LifecyclePhase) []stopHook {
+	return sc.removeStopHooks(func(h stopHook) bool {
+		return h.phase <= phase
+	})
+}
+
+func (sc *Scope) addStartHook(h startHook) {
+	sc.mu.Lock()
+	sc.startHooks = append(sc.startHooks, h)
+	sc.mu.Unlock()
+}
+
+func (sc *Scope) addStopHook(h stopHook) {
 	sc.mu.Lock()
-	sc.startHooks = append(sc.startHooks, f)
+	sc.stopHooks = append(sc.stopHooks, h)
 	sc.mu.Unlock()
 }
 
-func (sc *Scope) addStopHook(f StopFunc) {
+func (sc *Scope) addReloadHook(h reloadHook) {
 	sc.mu.Lock()
-	sc.stopHooks = append(sc.stopHooks, f)
+	sc.reloadHooks = append(sc.reloadHooks, h)
 	sc.mu.Unlock()
 }
 
+// takeStopHooks removes and returns the stop hooks owned by the given provider.
+func (sc *Scope) takeStopHooks(provider any) []stopHook {
+	return sc.removeStopHooks(func(h stopHook) bool {
+		return h.owner != nil && h.owner.provider == provider
+	})
+}
+
+// dropProviderHooks removes the hooks owned by an overwritten provider. Its stop hooks are
+// kept once the scope has started, the instance may be running.
+func (sc *Scope) dropProviderHooks(provider any) {
+	owned := func(owner *component) bool {
+		return owner != nil && owner.provider == provider
+	}
+	sc.takeReloadHooks(func(h reloadHook) bool { return owned(h.owner) })
+	if sc.CurState() != STATE_UNINITIALIZED {
+		return
+	}
+	sc.takeStopHooks(provider)
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	kept := sc.startHooks[:0]
+	for _, h := range sc.startHooks {
+		if !owned(h.owner) {
+			kept = append(kept, h)
+		}
+	}
+	for i := len(kept); i < len(sc.startHooks); i++ {
+		sc.startHooks[i] = startHook{}
+	}
+	sc.startHooks = kept
+}
+
+// takeReloadHooks removes and returns the reload hooks matching the predicate, in registration order.
+func (sc *Scope) takeReloadHooks(match func(h reloadHook) bool) []reloadHook {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	var taken []reloadHook
+	kept := sc.reloadHooks[:0]
+	for _, h := range sc.reloadHooks {
+		if match(h) {
+			taken = append(taken, h)
+		} else {
+			kept = append(kept, h)
+		}
+	}
+	for i := len(kept); i < len(sc.reloadHooks); i++ {
+		sc.reloadHooks[i] = reloadHook{}
+	}
+	sc.reloadHooks = kept
+	return taken
+}
+
+// removeStopHooks removes and returns the stop hooks matching the predicate, in registration order.
+func (sc *Scope) removeStopHooks(match func(h stopHook) bool) []stopHook {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	var taken []stopHook
+	kept := sc.stopHooks[:0]
+	for _, h := range sc.stopHooks {
+		if match(h) {
+			taken = append(taken, h)
+		} else {
+			kept = append(kept, h)
+		}
+	}
+	// Clear the tail so the removed closures can be collected.
+	for i := len(kept); i < len(sc.stopHooks); i++ {
+		sc.stopHooks[i] = stopHook{}
+	}
+	sc.stopHooks = kept
+	return taken
+}
+
 func (sc *Scope) changeState(newState LifeState) {
 	preState := atomic.SwapUint32((*uint32)(&sc.State), uint32(newState))
-	sc.Logger.Debug("ChangeState %v to %v", LifeState(preState), newState)
+	sc.Logger.log(LOG_DEBUG, "The state is changed.", Field{"scope", sc.Name}, Field{"from", LifeState(preState)}, Field{"to", newState})
+	if sc.observed() {
+		sc.emit(StateChanged{Scope: sc.Name, From: LifeState(preState), To: newState})
+	}
+}
+
+// providerSnapshot is the read-only copy of the providers of a sealed scope.
+// Plain maps are cheaper to read than sync.Map and are never written after sealing.
+type providerSnapshot struct {
+	types map[string]any
+	named map[string]any
+}
+
+func (snap *providerSnapshot) get(k string, isNamed bool) (any, bool) {
+	if isNamed {
+		val, ok := snap.named[k]
+		return val, ok
+	}
+	val, ok := snap.types[k]
+	return val, ok
+}
+
+// with returns a copy of the snapshot where k holds p, the snapshot itself is never modified.
+func (snap *providerSnapshot) with(k string, p any, isNamed bool) *providerSnapshot {
+	next := &providerSnapshot{types: snap.types, named: snap.named}
+	src := snap.types
+	if isNamed {
+		src = snap.named
+	}
+	dst := make(map[string]any, len(src)+1)
+	for key, val := range src {
+		dst[key] = val
+	}
+	dst[k] = p
+	if isNamed {
+		next.named = dst
+	} else {
+		next.types = dst
+	}
+	return next
 }
@@ -0,0 +1,55 @@
+package gdit_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/saweima12/gdit"
+)
+
+func TestSeal(t *testing.T) {
+	app := getTestApp()
+	app.Seal()
+
+	t.Run("AddProvider should fail after sealing", func(ct *testing.T) {
+		err := gdit.ProvideValue[*testConfig](&testConfig{}).Attach(app)
+		if !errors.Is(err, gdit.ErrSealed) {
+			ct.Fail()
+		}
+	})
+
+	t.Run("A scope created after sealing should be sealed", func(ct *testing.T) {
+		scope := app.GetScope("sealed")
+		err := gdit.ProvideValue[*testConfig](&testConfig{}).Attach(scope)
+		if !errors.Is(err, gdit.ErrSealed) {
+			ct.Fail()
+		}
+	})
+
+	t.Run("Lookups should read from the snapshot", func(ct *testing.T) {
+		gdit.InvokeFunc(app.GetScope("lookup"), func(ctx gdit.InvokeCtx) error {
+			if gdit.MustInject[*testConfig](ctx).DomainUrl != "http://example.com" {
+				ct.Fail()
+			}
+			if gdit.MustInjectNamed[TestService](ctx, "TestService") == nil {
+				ct.Fail()
+			}
+			return nil
+		})
+	})
+}
+
+func TestAutoSeal(t *testing.T) {
+	app := gdit.New(gdit.WithAutoSeal())
+	gdit.ProvideValue[*testConfig](&testConfig{}).Attach(app)
+
+	t.Run("The app should be sealed once ready", func(ct *testing.T) {
+		if err := app.Startup(); err != nil {
+			ct.Fatal(err)
+		}
+		err := gdit.ProvideValue[*testConfig](&testConfig{}).Attach(app)
+		if !errors.Is(err, gdit.ErrSealed) {
+			ct.Fail()
+		}
+	})
+}
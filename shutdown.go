@@ -0,0 +1,114 @@
+package gdit
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/saweima12/gdit/internal/utils"
+)
+
+// Shutdowner lets a component ask the app to shut down, for instance when a background
+// consumer hits a fatal error. Resolve it with Inject[Shutdowner], or from a start hook
+// with StartCtx.Shutdowner. The handle is safe to keep and to call from any goroutine.
+// The app provides it, registering another one fails with ErrReserved.
+type Shutdowner interface {
+	// Shutdown requests the shutdown of the app, err is nil for a clean shutdown.
+	// Only the first request is recorded, the later ones are ignored.
+	Shutdown(err error)
+}
+
+// ShutdownRequest describes which component asked for the shutdown and why.
+type ShutdownRequest struct {
+	// Component is the name of the requester, such as `*Consumer`, or `invoke` for an Invoke call.
+	Component string
+	Err       error
+}
+
+// shutdownState records the first shutdown requested by a component.
+type shutdownState struct {
+	once sync.Once
+	done chan struct{}
+	req  ShutdownRequest
+}
+
+func newShutdownState() *shutdownState {
+	return &shutdownState{done: make(chan struct{})}
+}
+
+// request records the request and closes done, later requests are ignored.
+func (s *shutdownState) request(req ShutdownRequest) {
+	s.once.Do(func() {
+		s.req = req
+		close(s.done)
+	})
+}
+
+type shutdownHandle struct {
+	state     *shutdownState
+	component string
+}
+
+func (h *shutdownHandle) Shutdown(err error) {
+	h.state.request(ShutdownRequest{Component: h.component, Err: err})
+}
+
+var shutdownerKey = utils.GetTypeKey[Shutdowner]()
+
+// shutdownProvider resolves a Shutdowner bound to the component injecting it.
+type shutdownProvider struct {
+	baseProvider
+	app *app
+}
+
+func newShutdownProvider(ap *app) *shutdownProvider {
+	return &shutdownProvider{
+		baseProvider: baseProvider{
+			key:    shutdownerKey,
+			name:   utils.GetType[Shutdowner](),
+			source: "builtin",
+		},
+		app: ap,
+	}
+}
+
+func (p *shutdownProvider) Get(ctx InvokeCtx) (Shutdowner, error) {
+	// The last element of the path is the Shutdowner itself.
+	component := "invoke"
+	if path := ctx.resolutionPath(); len(path) > 1 {
+		component = path[len(path)-2]
+	}
+	return &shutdownHandle{state: p.app.shutdown.Load(), component: component}, nil
+}
+
+func (p *shutdownProvider) kind() string {
+	return "builtin"
+}
+
+// attachShutdown installs the shutdown state of the app and registers its Shutdowner provider.
+func (ap *app) attachShutdown() {
+	ap.shutdown.Store(newShutdownState())
+	p := newShutdownProvider(ap)
+	// The map of a new app is empty, the registration cannot fail.
+	ap.AddProvider(p.Key(), p, p.IsNamed())
+}
+
+// checkReserved rejects the registration of p under a key the app provides itself.
+func checkReserved(sc *Scope, k string, p any, isNamed bool) error {
+	if isNamed || k != shutdownerKey {
+		return nil
+	}
+	if _, ok := p.(*shutdownProvider); ok {
+		return nil
+	}
+	return fmt.Errorf("[%s] -> The provider [%s] cannot be registered, the app provides it: %w", sc.Name, providerName(k, p), ErrReserved)
+}
+
+func (ap *app) Done() <-chan struct{} {
+	return ap.shutdown.Load().done
+}
+
+func (ap *app) Wait() ShutdownRequest {
+	state := ap.shutdown.Load()
+	<-state.done
+	return state.req
+}
@@ -0,0 +1,75 @@
+package gdit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/saweima12/gdit"
+)
+
+type testConsumer struct {
+	shutdowner gdit.Shutdowner
+}
+
+func TestShutdown(t *testing.T) {
+	errFatal := errors.New("fatal")
+
+	t.Run("An injected handle should report the requester", func(ct *testing.T) {
+		app := gdit.New()
+		gdit.Provide[*testConsumer](func(ctx gdit.InvokeCtx) (*testConsumer, error) {
+			return &testConsumer{shutdowner: gdit.MustInject[gdit.Shutdowner](ctx)}, nil
+		}).Attach(app)
+		consumer, _ := gdit.Invoke(app, func(ctx gdit.InvokeCtx) (*testConsumer, error) {
+			return gdit.Inject[*testConsumer](ctx)
+		})
+
+		select {
+		case <-app.Done():
+			ct.Fatal("the shutdown should not be requested yet")
+		default:
+		}
+
+		consumer.shutdowner.Shutdown(errFatal)
+		consumer.shutdowner.Shutdown(nil)
+		<-app.Done()
+		req := app.Wait()
+		if req.Component != "*gdit_test.testConsumer" || req.Err != errFatal {
+			ct.Errorf("unexpected request %+v", req)
+		}
+	})
+
+	t.Run("A start hook should stop Run through its context", func(ct *testing.T) {
+		app := gdit.New()
+		stopped := false
+		gdit.InvokeFunc(app, func(ctx gdit.InvokeCtx) error {
+			ctx.OnStart(func(startCtx gdit.StartCtx) error {
+				shutdowner := startCtx.Shutdowner()
+				go shutdowner.Shutdown(errFatal)
+				return nil
+			})
+			ctx.OnStop(func(stopCtx gdit.StopCtx) error {
+				stopped = true
+				return nil
+			})
+			return nil
+		})
+
+		res := app.Run(context.Background())
+		if !stopped || !errors.Is(res.Err, errFatal) || res.Reason != "shutdown requested by invoke" {
+			ct.Errorf("unexpected result %+v", res)
+		}
+		if res.ExitCode() != 1 {
+			ct.Errorf("unexpected exit code %d", res.ExitCode())
+		}
+	})
+
+	t.Run("The Shutdowner should not be registered by the application", func(ct *testing.T) {
+		app := gdit.New(gdit.WithDuplicatePolicy(gdit.DUPLICATE_KEEP_FIRST))
+		var shutdowner gdit.Shutdowner
+		err := gdit.ProvideValue(shutdowner).Attach(app)
+		if !errors.Is(err, gdit.ErrReserved) {
+			ct.Errorf("unexpected error %v", err)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+//go:build go1.21
+
+package gdit
+
+import (
+	stdctx "context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// NewSlogLogger adapts a *slog.Logger, so the container logs are written by its handler
+// with their attributes, such as the scope, the provider kind or the hook name.
+// [logger] -> The logger receiving the container logs.
+// [level] -> The LevelVar of the handler, or nil. SetLogLevel sets it, so the levels of the app
+// and of the handler stay in sync. The app always asks the handler which levels are enabled.
+// Returns a StructuredLogger to pass to SetLogger.
+func NewSlogLogger(logger *slog.Logger, level *slog.LevelVar) StructuredLogger {
+	return &slogLogger{l: logger, level: level}
+}
+
+type slogLogger struct {
+	l     *slog.Logger
+	level *slog.LevelVar
+}
+
+func (sl *slogLogger) Debug(format string, args ...any) {
+	sl.l.Log(stdctx.Background(), slog.LevelDebug, fmt.Sprintf(format, args...))
+}
+
+func (sl *slogLogger) Info(format string, args ...any) {
+	sl.l.Log(stdctx.Background(), slog.LevelInfo, fmt.Sprintf(format, args...))
+}
+
+func (sl *slogLogger) Warn(format string, args ...any) {
+	sl.l.Log(stdctx.Background(), slog.LevelWarn, fmt.Sprintf(format, args...))
+}
+
+func (sl *slogLogger) Error(format string, args ...any) {
+	sl.l.Log(stdctx.Background(), slog.LevelError, fmt.Sprintf(format, args...))
+}
+
+func (sl *slogLogger) Log(level LogLevel, msg string, fields ...Field) {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, f := range fields {
+		switch v := f.Value.(type) {
+		case time.Duration, error:
+			attrs = append(attrs, slog.Any(f.Key, v))
+		case fmt.Stringer:
+			// The states and phases are logged by name.
+			attrs = append(attrs, slog.String(f.Key, v.String()))
+		default:
+			attrs = append(attrs, slog.Any(f.Key, v))
+		}
+	}
+	sl.l.LogAttrs(stdctx.Background(), slogLevel(level), msg, attrs...)
+}
+
+func (sl *slogLogger) enabled(level LogLevel) bool {
+	return sl.l.Enabled(stdctx.Background(), slogLevel(level))
+}
+
+func (sl *slogLogger) setLevel(level LogLevel) {
+	if sl.level != nil {
+		sl.level.Set(slogLevel(level))
+	}
+}
+
+func slogLevel(level LogLevel) slog.Level {
+	switch level {
+	case LOG_DEBUG:
+		return slog.LevelDebug
+	case LOG_INFO:
+		return slog.LevelInfo
+	case LOG_WARN:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
@@ -0,0 +1,105 @@
+//go:build go1.21
+
+package gdit_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/saweima12/gdit"
+)
+
+// decodeLogs parses the JSON lines written by a slog.JSONHandler.
+func decodeLogs(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		entry := map[string]any{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestSlogLogger(t *testing.T) {
+	t.Run("The container logs should carry their attributes", func(ct *testing.T) {
+		buf := &bytes.Buffer{}
+		level := &slog.LevelVar{}
+		level.Set(slog.LevelDebug)
+		app := gdit.New().SetLogger(gdit.NewSlogLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: level})), level))
+		gdit.ProvideValue[*testClock](&testClock{}).Attach(app)
+
+		entries := decodeLogs(ct, buf)
+		if len(entries) != 1 {
+			ct.Fatalf("unexpected logs %s", buf)
+		}
+		entry := entries[0]
+		if entry["msg"] != "The provider is registered." || entry["scope"] != "root" ||
+			entry["provider"] != "*gdit_test.testClock" || entry["kind"] != "value" {
+			ct.Errorf("unexpected log %v", entry)
+		}
+	})
+
+	t.Run("The hooks should be logged with their phase and duration", func(ct *testing.T) {
+		buf := &bytes.Buffer{}
+		level := &slog.LevelVar{}
+		level.Set(slog.LevelDebug)
+		app := gdit.New().SetLogger(gdit.NewSlogLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: level})), level))
+		addTestComponent(app, &testRecorder{}, "c1", false, false)
+		app.Startup()
+
+		for _, entry := range decodeLogs(ct, buf) {
+			if entry["msg"] != "The hook has run." {
+				continue
+			}
+			if entry["phase"] != "Start" || entry["owner"] != "invoke" || entry["duration"] == nil {
+				ct.Errorf("unexpected log %v", entry)
+			}
+			return
+		}
+		ct.Errorf("the hook is not logged: %s", buf)
+	})
+
+	t.Run("A failing start hook should be logged with its error", func(ct *testing.T) {
+		buf := &bytes.Buffer{}
+		level := &slog.LevelVar{}
+		app := gdit.New().SetLogger(gdit.NewSlogLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: level})), level))
+		addTestComponent(app, &testRecorder{}, "c1", true, false)
+		app.Startup()
+
+		for _, entry := range decodeLogs(ct, buf) {
+			if entry["msg"] != "The hook failed." {
+				continue
+			}
+			if entry["level"] != "ERROR" || entry["phase"] != "Start" || entry["owner"] != "invoke" || entry["error"] == nil {
+				ct.Errorf("unexpected log %v", entry)
+			}
+			return
+		}
+		ct.Errorf("the hook is not logged: %s", buf)
+	})
+
+	t.Run("The levels of the app and the handler should stay in sync", func(ct *testing.T) {
+		buf := &bytes.Buffer{}
+		level := &slog.LevelVar{}
+		level.Set(slog.LevelDebug)
+		app := gdit.New().SetLogger(gdit.NewSlogLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: level})), level))
+
+		app.SetLogLevel(gdit.LOG_WARN)
+		if level.Level() != slog.LevelWarn {
+			ct.Errorf("unexpected handler level %v", level.Level())
+		}
+		level.Set(slog.LevelError)
+		gdit.ProvideValue[*testClock](&testClock{}).Attach(app)
+		if buf.Len() != 0 {
+			ct.Errorf("unexpected logs %s", buf)
+		}
+	})
+}
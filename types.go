@@ -1,12 +1,14 @@
 package gdit
 
 type Container interface {
-	AddProvider(k string, p any, isNamed bool)
+	AddProvider(k string, p any, isNamed bool) error
 	GetProvider(k string, isNamed bool) (any, bool)
 	getLogger() Logger
 	CurState() LifeState
-	addStartHook(f StartFunc)
-	addStopHook(f StopFunc)
+	addStartHook(h startHook)
+	addStopHook(h stopHook)
+	addReloadHook(h reloadHook)
+	getScope() *Scope
 }
 
 type CtorFunc[T any] func(ctx InvokeCtx) (T, error)
@@ -14,3 +16,4 @@ type HookFunc func(ctx Context) error
 
 type StartFunc func(startCtx StartCtx) error
 type StopFunc func(stopCtx StopCtx) error
+type ReloadFunc func(reloadCtx ReloadCtx) error